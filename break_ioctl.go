@@ -0,0 +1,19 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd netbsd openbsd solaris
+
+package pty
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// sendBreak sets the break condition on the master end for d.
+func (p *unixPty) sendBreak(d time.Duration) error {
+	if err := p.ioctlMaster(unix.TIOCSBRK); err != nil {
+		return err
+	}
+	time.Sleep(d)
+	return p.ioctlMaster(unix.TIOCCBRK)
+}
@@ -0,0 +1,9 @@
+package pty
+
+import "time"
+
+// sendBreak returns ErrUnsupported, as the Linux pseudo-terminal driver
+// accepts TIOCSBRK and TIOCCBRK without passing a break to the slave end.
+func (*unixPty) sendBreak(time.Duration) error {
+	return ErrUnsupported
+}
@@ -2,8 +2,10 @@ package pty
 
 import (
 	"context"
+	"io"
 	"os"
 	"syscall"
+	"time"
 )
 
 // Cmd is a command that can be started attached to a pseudo-terminal.
@@ -12,11 +14,24 @@ import (
 // This is required as we cannot use exec.Cmd directly on Windows due to
 // limitation of starting a process attached to a pseudo-terminal.
 // See: https://github.com/golang/go/issues/62708
+//
+// A pseudo-terminal runs one command at a time. Another command can be
+// started on it once the previous one has exited; on Unix, call Reset in
+// between to start from a clean terminal state.
 type Cmd struct {
 	ctx context.Context
 	pty Pty
 	sys interface{}
 
+	// startTime and exitTime are when the process was started and when it
+	// was seen exiting, see ExitStatus.
+	startTime, exitTime time.Time
+
+	// Hooks registered with OnPreExec, OnStart and OnExit.
+	onPreExec []func(*Cmd) error
+	onStart   []func(*Cmd)
+	onExit    []func(*Cmd, error)
+
 	// Path is the path of the command to run.
 	Path string
 
@@ -27,10 +42,55 @@ type Cmd struct {
 	// If Env is nil, the new process uses the current process's environment.
 	Env []string
 
+	// TerminalEnv adds the variables describing the pseudo-terminal to the
+	// environment of the process: TERM, COLORTERM, COLUMNS and LINES from the
+	// current size, and, on Unix, TTY and SSH_TTY from the name of the
+	// pseudo-terminal. They replace inherited variables of the same name, but
+	// variables set in Env take priority.
+	TerminalEnv bool
+
+	// Term is the value of TERM set by TerminalEnv. If Term is empty,
+	// DefaultTerm is used.
+	Term string
+
+	// User, if set, runs the process as another user, given by name or
+	// numeric user ID, the way login(1) does. The process gets the user ID,
+	// primary group and supplementary groups of the user, and HOME, SHELL,
+	// USER and LOGNAME are set in its environment unless set in Env. The
+	// slave end is owned by the user and the tty group with mode 0620 until
+	// the pseudo-terminal is closed. This requires privileges, cannot be
+	// combined with SysProcAttr.Credential, and is not supported on Windows.
+	User string
+
+	// Group, if set along with User, is the group name or numeric group ID to
+	// run the process with instead of the primary group of the user.
+	Group string
+
 	// Dir specifies the working directory of the command.
 	// If Dir is the empty string, the current directory is used.
 	Dir string
 
+	// Stdin specifies the process's standard input.
+	// If Stdin is nil, the process reads from the pseudo-terminal. Otherwise,
+	// the pseudo-terminal is still made the controlling terminal of the
+	// process and is passed to it as the file descriptor following
+	// ExtraFiles, unless SysProcAttr.Ctty is set, in which case the caller
+	// passes it at that file descriptor. Setting Stdin is not supported on
+	// Windows.
+	Stdin io.Reader
+
+	// Stdout and Stderr specify the process's standard output and error.
+	// If either is nil, the process writes it to the pseudo-terminal.
+	// Otherwise, they are handled like the fields of the same name in
+	// exec.Cmd. Setting them is not supported on Windows.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ExtraFiles specifies additional open files to be inherited by the
+	// process. As with exec.Cmd, entry i becomes file descriptor 3+i.
+	// This is not supported on Windows.
+	ExtraFiles []*os.File
+
 	// SysProcAttr holds optional, operating system-specific attributes.
 	SysProcAttr *syscall.SysProcAttr
 
@@ -44,16 +104,88 @@ type Cmd struct {
 
 	// Cancel is called when the command is canceled.
 	Cancel func() error
+
+	// KillGracePeriod enables graceful cancellation of commands created with
+	// CommandContext that have no Cancel function set. When the context is
+	// done, the session of the command is sent SIGHUP, then SIGTERM once
+	// KillGracePeriod has elapsed, and SIGKILL once it has elapsed again,
+	// stopping as soon as the process group is gone. If KillGracePeriod is
+	// zero, the process is killed right away. On Windows, the process is
+	// always killed right away.
+	KillGracePeriod time.Duration
+
+	// WaitDelay bounds the time spent in Wait after the context is done, as
+	// in exec.Cmd. Once it elapses, the process is killed. If WaitDelay is
+	// zero, Wait blocks until the process exits.
+	WaitDelay time.Duration
+
+	// DrainOutput makes Wait block, after the process exits, until all the
+	// output it wrote to the pseudo-terminal has been read from the master
+	// end. Once drained, reads from the pseudo-terminal return io.EOF until
+	// the next command is started, so that io.Copy from the pseudo-terminal
+	// returns with the full output. The output must be read concurrently for
+	// Wait to return. Interrupting a pending read requires the non-blocking
+	// master end, see WithNonblock; otherwise, Start returns an error wrapping
+	// ErrUnsupported and os.ErrNoDeadline. This has no effect on Windows.
+	DrainOutput bool
+
+	// OutputLimit, if positive, is the maximum number of bytes Output and
+	// CombinedOutput keep of the output and of the standard error. The rest
+	// is read and discarded.
+	OutputLimit int
 }
 
 // Start starts the specified command attached to the pseudo-terminal.
 func (c *Cmd) Start() error {
-	return c.start()
+	for _, f := range c.onPreExec {
+		if err := f(c); err != nil {
+			return err
+		}
+	}
+
+	startTime := time.Now()
+	if err := c.start(); err != nil {
+		return err
+	}
+	c.startTime = startTime
+
+	for _, f := range c.onStart {
+		f(c)
+	}
+	return nil
 }
 
 // Wait waits for the command to exit.
+// If the command exits unsuccessfully, the error is of type *ExitError.
 func (c *Cmd) Wait() error {
-	return c.wait()
+	waited := c.ProcessState != nil
+	err := c.wait()
+	if !waited && c.ProcessState != nil {
+		for _, f := range c.onExit {
+			f(c, err)
+		}
+	}
+	return err
+}
+
+// OnPreExec registers f to be called by Start before the process is created.
+// If f returns an error, Start returns it without starting the process.
+// Hooks are called in the order they were registered.
+func (c *Cmd) OnPreExec(f func(*Cmd) error) {
+	c.onPreExec = append(c.onPreExec, f)
+}
+
+// OnStart registers f to be called by Start once the process has started.
+// Hooks are called in the order they were registered.
+func (c *Cmd) OnStart(f func(*Cmd)) {
+	c.onStart = append(c.onStart, f)
+}
+
+// OnExit registers f to be called by Wait once the process has exited, with
+// the error Wait returns, including when the command was canceled through
+// its context. Hooks are called in the order they were registered.
+func (c *Cmd) OnExit(f func(*Cmd, error)) {
+	c.onExit = append(c.onExit, f)
 }
 
 // Run runs the command and waits for it to complete.
@@ -3,6 +3,8 @@
 
 package pty
 
+import "os"
+
 func (*Cmd) start() error {
 	return ErrUnsupported
 }
@@ -10,3 +12,11 @@ func (*Cmd) start() error {
 func (*Cmd) wait() error {
 	return ErrUnsupported
 }
+
+func exitSignal(*os.ProcessState) (os.Signal, bool) {
+	return nil, false
+}
+
+func maxRSS(*os.ProcessState) int64 {
+	return 0
+}
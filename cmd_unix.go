@@ -5,12 +5,17 @@ package pty
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
 
-func (c *Cmd) start() error {
+func (c *Cmd) start() (retErr error) {
 	if c.Process != nil {
 		return errors.New("exec: already started")
 	}
@@ -19,12 +24,28 @@ func (c *Cmd) start() error {
 	if !ok {
 		return ErrInvalidCommand
 	}
+	if pty.isClosed() {
+		return ErrClosed
+	}
+	if pty.slave == nil {
+		return errNoSlave
+	}
+	if c.DrainOutput {
+		// Draining ends with interrupting the reader, which a blocking
+		// master cannot do, so refuse now rather than fail in Wait.
+		if err := pty.master.SetReadDeadline(time.Time{}); err != nil {
+			return fmt.Errorf("%w: DrainOutput requires read deadlines: %w", ErrUnsupported, err)
+		}
+	}
 
 	cmd := exec.Command(c.Path, c.Args[1:]...)
 	if c.ctx != nil {
 		cmd = exec.CommandContext(c.ctx, c.Path, c.Args[1:]...)
 		if c.Cancel == nil {
 			c.Cancel = func() error {
+				if c.KillGracePeriod > 0 {
+					return c.terminate(pty, cmd.Process.Pid)
+				}
 				return cmd.Process.Kill()
 			}
 		}
@@ -32,18 +53,72 @@ func (c *Cmd) start() error {
 	c.sys = cmd
 
 	cmd.Dir = c.Dir
-	cmd.Env = c.Env
+	cmd.ExtraFiles = append([]*os.File(nil), c.ExtraFiles...)
 	cmd.Cancel = c.Cancel
-	cmd.SysProcAttr = c.SysProcAttr
-	if cmd.SysProcAttr == nil {
-		cmd.SysProcAttr = &unix.SysProcAttr{}
+	cmd.WaitDelay = c.WaitDelay
+	cmd.SysProcAttr = &unix.SysProcAttr{}
+	if c.SysProcAttr != nil {
+		// Work on a copy, as the attributes may be shared with other
+		// commands.
+		attr := *c.SysProcAttr
+		cmd.SysProcAttr = &attr
+	}
+
+	var vars []string
+	if c.User != "" {
+		if c.SysProcAttr != nil && c.SysProcAttr.Credential != nil {
+			return errors.New("pty: both User and SysProcAttr.Credential are set")
+		}
+		login, err := lookupLogin(c.User, c.Group)
+		if err != nil {
+			return err
+		}
+		if err := login.setup(pty); err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				_ = pty.restoreOwner()
+			}
+		}()
+		// This sets the credentials on the copy of SysProcAttr only.
+		cmd.SysProcAttr.Credential = login.credential()
+		vars = append(vars, login.environ()...)
+	}
+	if c.TerminalEnv {
+		vars = append(vars, c.terminalEnv()...)
+	}
+	cmd.Env = c.Env
+	if len(vars) > 0 {
+		env := c.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = c.mergeEnv(env, vars)
 	}
 
-	cmd.Stdin = pty.slave
-	cmd.Stdout = pty.slave
-	cmd.Stderr = pty.slave
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	if cmd.Stdin == nil {
+		cmd.Stdin = pty.slave
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = pty.slave
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = pty.slave
+	}
 	cmd.SysProcAttr.Setsid = true
 	cmd.SysProcAttr.Setctty = true
+	if cmd.Stdin != pty.slave && cmd.SysProcAttr.Ctty == 0 {
+		// Ctty is a file descriptor in the child, so the slave has to be
+		// passed along when it isn't the standard input, unless the caller
+		// already did.
+		cmd.SysProcAttr.Ctty = 3 + len(cmd.ExtraFiles)
+		cmd.ExtraFiles = append(cmd.ExtraFiles, pty.slave)
+	}
+	pty.resume()
 	if err := cmd.Start(); err != nil {
 		return err
 	}
@@ -66,5 +141,72 @@ func (c *Cmd) wait() error {
 	}
 	err := cmd.Wait()
 	c.ProcessState = cmd.ProcessState
+	c.exitTime = time.Now()
+	if ee, ok := err.(*exec.ExitError); ok {
+		err = &ExitError{ExitError: ee}
+	}
+	if c.DrainOutput {
+		if drainErr := c.pty.(*unixPty).drainOutput(c.ctx); err == nil {
+			err = drainErr
+		}
+	}
+	return err
+}
+
+// exitSignal returns the signal that terminated the process, if any, and
+// whether it dumped core.
+func exitSignal(state *os.ProcessState) (os.Signal, bool) {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return nil, false
+	}
+	return ws.Signal(), ws.CoreDump()
+}
+
+// maxRSS returns the maximum resident set size of the process in bytes.
+func maxRSS(state *os.ProcessState) int64 {
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	// Darwin reports bytes, the other systems kilobytes.
+	if runtime.GOOS == "darwin" {
+		return int64(ru.Maxrss)
+	}
+	return int64(ru.Maxrss) * 1024
+}
+
+// terminate hangs up the session led by pid and escalates to SIGTERM and then
+// SIGKILL, KillGracePeriod apart, until the process group is gone.
+func (c *Cmd) terminate(pty *unixPty, pid int) error {
+	if err := signalSession(pty, pid, unix.SIGHUP); err != nil {
+		return err
+	}
+	go func() {
+		for _, sig := range []syscall.Signal{unix.SIGTERM, unix.SIGKILL} {
+			time.Sleep(c.KillGracePeriod)
+			if err := signalSession(pty, pid, sig); err != nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// signalSession sends sig to the process group of the session leader pid and
+// to the foreground process group of the pseudo-terminal if it belongs to the
+// same session, as job control shells run their jobs in their own groups. It
+// returns os.ErrProcessDone if the process group of the session leader is
+// gone.
+func signalSession(pty *unixPty, pid int, sig syscall.Signal) error {
+	if pgrp, err := pty.ForegroundPgrp(); err == nil && pgrp != pid {
+		if sid, err := unix.Getsid(pgrp); err == nil && sid == pid {
+			_ = unix.Kill(-pgrp, sig)
+		}
+	}
+	err := unix.Kill(-pid, sig)
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	}
 	return err
 }
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 	"unicode/utf16"
 	"unsafe"
 
@@ -18,9 +19,12 @@ import (
 )
 
 type conPtySys struct {
-	attrs  *windows.ProcThreadAttributeListContainer
-	done   chan error
-	cmdErr error
+	attrs *windows.ProcThreadAttributeListContainer
+	done  chan error
+
+	// waitDone is closed once waitOnContext has returned and set cmdErr.
+	waitDone chan struct{}
+	cmdErr   error
 }
 
 func (c *Cmd) start() error {
@@ -28,6 +32,9 @@ func (c *Cmd) start() error {
 	if !ok {
 		return ErrInvalidCommand
 	}
+	if c.Stdin != nil || c.Stdout != nil || c.Stderr != nil || len(c.ExtraFiles) > 0 || c.User != "" {
+		return ErrUnsupported
+	}
 
 	if c.SysProcAttr == nil {
 		c.SysProcAttr = &syscall.SysProcAttr{}
@@ -73,12 +80,16 @@ func (c *Cmd) start() error {
 		}
 	}
 
-	if c.Env == nil {
-		c.Env, err = execEnvDefault(c.SysProcAttr)
+	env := c.Env
+	if env == nil {
+		env, err = execEnvDefault(c.SysProcAttr)
 		if err != nil {
 			return err
 		}
 	}
+	if c.TerminalEnv {
+		env = c.mergeEnv(env, c.terminalEnv())
+	}
 
 	siEx := new(windows.StartupInfoEx)
 	siEx.Flags = windows.STARTF_USESTDHANDLES
@@ -96,8 +107,9 @@ func (c *Cmd) start() error {
 	}
 
 	c.sys = &conPtySys{
-		attrs: attrs,
-		done:  make(chan error, 1),
+		attrs:    attrs,
+		done:     make(chan error, 1),
+		waitDone: make(chan struct{}),
 	}
 
 	if err := pty.updateProcThreadAttribute(attrs); err != nil {
@@ -131,7 +143,7 @@ func (c *Cmd) start() error {
 			tSec,
 			false,
 			flags,
-			createEnvBlock(addCriticalEnv(dedupEnvCase(true, c.Env))),
+			createEnvBlock(addCriticalEnv(dedupEnvCase(true, env))),
 			dirp,
 			&siEx.StartupInfo,
 			pi,
@@ -144,7 +156,7 @@ func (c *Cmd) start() error {
 			tSec,
 			false,
 			flags,
-			createEnvBlock(addCriticalEnv(dedupEnvCase(true, c.Env))),
+			createEnvBlock(addCriticalEnv(dedupEnvCase(true, env))),
 			dirp,
 			&siEx.StartupInfo,
 			pi,
@@ -179,12 +191,24 @@ func (c *Cmd) start() error {
 
 func (c *Cmd) waitOnContext() {
 	sys := c.sys.(*conPtySys)
+	defer close(sys.waitDone)
 	select {
 	case <-c.ctx.Done():
-		sys.cmdErr = c.Cancel()
+		if c.Cancel != nil {
+			sys.cmdErr = c.Cancel()
+		}
 		if sys.cmdErr == nil {
 			sys.cmdErr = c.ctx.Err()
 		}
+		if c.WaitDelay > 0 {
+			timer := time.NewTimer(c.WaitDelay)
+			defer timer.Stop()
+			select {
+			case <-sys.done:
+			case <-timer.C:
+				_ = c.Process.Kill()
+			}
+		}
 	case err := <-sys.done:
 		sys.cmdErr = err
 	}
@@ -200,21 +224,38 @@ func (c *Cmd) wait() (retErr error) {
 	defer func() {
 		sys := c.sys.(*conPtySys)
 		sys.attrs.Delete()
-		sys.done <- nil
+		if c.ctx != nil {
+			// Wait for waitOnContext so that reading cmdErr doesn't race
+			// with a concurrent cancellation.
+			sys.done <- nil
+			<-sys.waitDone
+		}
 		if retErr == nil {
 			retErr = sys.cmdErr
 		}
 	}()
 	c.ProcessState, retErr = c.Process.Wait()
+	c.exitTime = time.Now()
 	if retErr != nil {
 		return retErr
 	}
 	if !c.ProcessState.Success() {
-		retErr = &exec.ExitError{ProcessState: c.ProcessState}
+		retErr = &ExitError{ExitError: &exec.ExitError{ProcessState: c.ProcessState}}
 	}
 	return
 }
 
+// exitSignal returns nil, as Windows processes are not terminated by signals.
+func exitSignal(*os.ProcessState) (os.Signal, bool) {
+	return nil, false
+}
+
+// maxRSS returns 0, as the peak memory usage of the process is not known
+// once it has been waited on.
+func maxRSS(*os.ProcessState) int64 {
+	return 0
+}
+
 //
 // Below are a bunch of helpers for working with Windows' CreateProcess family of functions. These are mostly exact copies of the same utilities
 // found in the go stdlib.
@@ -374,31 +415,6 @@ func createEnvBlock(envv []string) *uint16 {
 	return &utf16.Encode([]rune(string(b)))[0]
 }
 
-// dedupEnvCase is dedupEnv with a case option for testing.
-// If caseInsensitive is true, the case of keys is ignored.
-func dedupEnvCase(caseInsensitive bool, env []string) []string {
-	out := make([]string, 0, len(env))
-	saw := make(map[string]int, len(env)) // key => index into out
-	for _, kv := range env {
-		eq := strings.Index(kv, "=")
-		if eq < 0 {
-			out = append(out, kv)
-			continue
-		}
-		k := kv[:eq]
-		if caseInsensitive {
-			k = strings.ToLower(k)
-		}
-		if dupIdx, isDup := saw[k]; isDup {
-			out[dupIdx] = kv
-			continue
-		}
-		saw[k] = len(out)
-		out = append(out, kv)
-	}
-	return out
-}
-
 // addCriticalEnv adds any critical environment variables that are required
 // (or at least almost always required) on the operating system.
 // Currently this is only used for Windows.
@@ -0,0 +1,69 @@
+package pty
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultTerm is the value of TERM set for commands started with TerminalEnv
+// when Cmd.Term is empty.
+var DefaultTerm = "xterm-256color"
+
+// terminalEnv returns the variables describing the pseudo-terminal, see
+// TerminalEnv.
+func (c *Cmd) terminalEnv() []string {
+	term := c.Term
+	if term == "" {
+		term = DefaultTerm
+	}
+	vars := []string{"TERM=" + term, "COLORTERM=truecolor"}
+	if width, height, err := c.pty.Size(); err == nil && width > 0 && height > 0 {
+		vars = append(vars,
+			"COLUMNS="+strconv.Itoa(width),
+			"LINES="+strconv.Itoa(height),
+		)
+	}
+	if _, ok := c.pty.(UnixPty); ok {
+		vars = append(vars, "TTY="+c.pty.Name(), "SSH_TTY="+c.pty.Name())
+	}
+	return vars
+}
+
+// mergeEnv adds vars to env, either Env or the inherited environment. The
+// variables replace inherited ones of the same name, but not those set in
+// Env.
+func (c *Cmd) mergeEnv(env []string, vars []string) []string {
+	// Later entries win, so variables set in Env go last.
+	if c.Env != nil {
+		env = append(vars, env...)
+	} else {
+		env = append(env[:len(env):len(env)], vars...)
+	}
+	return dedupEnvCase(runtime.GOOS == "windows", env)
+}
+
+// dedupEnvCase is dedupEnv with a case option for testing.
+// If caseInsensitive is true, the case of keys is ignored.
+func dedupEnvCase(caseInsensitive bool, env []string) []string {
+	out := make([]string, 0, len(env))
+	saw := make(map[string]int, len(env)) // key => index into out
+	for _, kv := range env {
+		eq := strings.Index(kv, "=")
+		if eq < 0 {
+			out = append(out, kv)
+			continue
+		}
+		k := kv[:eq]
+		if caseInsensitive {
+			k = strings.ToLower(k)
+		}
+		if dupIdx, isDup := saw[k]; isDup {
+			out[dupIdx] = kv
+			continue
+		}
+		saw[k] = len(out)
+		out = append(out, kv)
+	}
+	return out
+}
@@ -1,19 +1,19 @@
 module examples
 
-go 1.20
+go 1.25.0
 
 replace github.com/aymanbagabas/go-pty => ../
 
 require (
 	github.com/aymanbagabas/go-pty v0.0.0-00010101000000-000000000000
 	github.com/charmbracelet/ssh v0.0.0-20230822194956-1a051f898e09
-	golang.org/x/term v0.27.0
+	golang.org/x/term v0.44.0
 )
 
 require (
 	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be // indirect
-	github.com/creack/pty v1.1.21 // indirect
-	github.com/u-root/u-root v0.13.1 // indirect
-	golang.org/x/crypto v0.31.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
+	github.com/creack/pty v1.1.24 // indirect
+	github.com/u-root/u-root v0.16.0 // indirect
+	golang.org/x/crypto v0.53.0 // indirect
+	golang.org/x/sys v0.46.0 // indirect
 )
@@ -19,14 +19,20 @@ func main() {
 			}
 
 			defer pseudo.Close()
-			w, h := ptyReq.Window.Width, ptyReq.Window.Height
+			win := ptyReq.Window
 			if ptyReq.Modes != nil {
-				if err := pty.ApplyTerminalModes(int(pseudo.Fd()), w, h, ptyReq.Modes); err != nil {
+				ws := &pty.Winsize{
+					Row:    uint16(win.Height),
+					Col:    uint16(win.Width),
+					Xpixel: uint16(win.WidthPixels),
+					Ypixel: uint16(win.HeightPixels),
+				}
+				if err := pty.ApplyTerminalModesWinsize(int(pseudo.Fd()), ws, ptyReq.Modes); err != nil {
 					log.Println(err)
 					return
 				}
 			}
-			if err := pseudo.Resize(w, h); err != nil {
+			if err := pseudo.ResizeWithPixels(win.Width, win.Height, win.WidthPixels, win.HeightPixels); err != nil {
 				log.Println(err)
 				return
 			}
@@ -41,7 +47,7 @@ func main() {
 
 			go func() {
 				for win := range winCh {
-					pseudo.Resize(win.Width, win.Height)
+					pseudo.ResizeWithPixels(win.Width, win.Height, win.WidthPixels, win.HeightPixels)
 				}
 			}()
 
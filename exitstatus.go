@@ -0,0 +1,70 @@
+package pty
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// ExitError is returned by Wait, Run, Output and CombinedOutput when the
+// command exits unsuccessfully.
+type ExitError struct {
+	*exec.ExitError
+
+	// Output holds the output captured from the pseudo-terminal by Output
+	// and CombinedOutput.
+	Output []byte
+}
+
+// Unwrap returns the underlying exec.ExitError.
+func (e *ExitError) Unwrap() error {
+	return e.ExitError
+}
+
+// ExitStatus describes how a command exited and the resources it used.
+type ExitStatus struct {
+	// Code is the exit code of the process, or -1 if it was terminated by a
+	// signal.
+	Code int
+
+	// Signal is the signal that terminated the process, or nil if it exited
+	// on its own. It is always nil on Windows.
+	Signal os.Signal
+
+	// CoreDump reports whether the process dumped core when terminated by
+	// Signal.
+	CoreDump bool
+
+	// WallTime is the time elapsed between starting the process and seeing
+	// it exit.
+	WallTime time.Duration
+
+	// UserTime and SystemTime are the CPU time spent by the process in user
+	// and kernel mode.
+	UserTime   time.Duration
+	SystemTime time.Duration
+
+	// MaxRSS is the maximum resident set size of the process in bytes, or 0
+	// if it is unknown, as on Windows.
+	MaxRSS int64
+}
+
+// ExitStatus returns how the command exited. It returns nil until Wait or
+// Run has seen the process exit.
+func (c *Cmd) ExitStatus() *ExitStatus {
+	state := c.ProcessState
+	if state == nil {
+		return nil
+	}
+
+	sig, core := exitSignal(state)
+	return &ExitStatus{
+		Code:       state.ExitCode(),
+		Signal:     sig,
+		CoreDump:   core,
+		WallTime:   c.exitTime.Sub(c.startTime),
+		UserTime:   state.UserTime(),
+		SystemTime: state.SystemTime(),
+		MaxRSS:     maxRSS(state),
+	}
+}
@@ -0,0 +1,15 @@
+package pty
+
+// Metadata is the session metadata transferred along with a pseudo-terminal
+// by Send.
+type Metadata struct {
+	// Pid is the process ID of the command running on the pseudo-terminal,
+	// or zero.
+	Pid int `json:"pid,omitempty"`
+
+	// Winsize is the window size of the pseudo-terminal. Send fills it in.
+	Winsize Winsize `json:"winsize"`
+
+	// Data holds arbitrary application data.
+	Data []byte `json:"data,omitempty"`
+}
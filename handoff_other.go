@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !netbsd && !openbsd && !solaris
+// +build !linux,!darwin,!freebsd,!dragonfly,!netbsd,!openbsd,!solaris
+
+package pty
+
+import (
+	"net"
+)
+
+// Send sends the pseudo-terminal and the session metadata over a Unix
+// socket. It is only supported on Unix.
+func Send(*net.UnixConn, UnixPty, *Metadata) error {
+	return ErrUnsupported
+}
+
+// Receive receives a pseudo-terminal and its session metadata sent with
+// Send. It is only supported on Unix.
+func Receive(*net.UnixConn, ...Option) (UnixPty, *Metadata, error) {
+	return nil, nil, ErrUnsupported
+}
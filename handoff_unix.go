@@ -0,0 +1,188 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxMetadataSize is the maximum size of the encoded metadata accepted by
+// Receive.
+const maxMetadataSize = 1 << 20
+
+// Send sends the pseudo-terminal and the session metadata over a Unix
+// socket to a process calling Receive. The master end, and the slave end if
+// any, are passed as SCM_RIGHTS ancillary data, and the metadata is sent
+// with the current window size filled in. The pseudo-terminal remains open
+// in the sending process, which should close it once sent.
+func Send(conn *net.UnixConn, p UnixPty, meta *Metadata) error {
+	var m Metadata
+	if meta != nil {
+		m = *meta
+	}
+
+	ws, err := p.Winsize()
+	if err != nil {
+		return err
+	}
+	m.Winsize = *ws
+
+	data, err := json.Marshal(&m)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+
+	var n int
+	var sendErr error
+	send := func(fds ...int) {
+		n, _, sendErr = conn.WriteMsgUnix(buf, unix.UnixRights(fds...), nil)
+	}
+	if err := controlFile(p.Master(), func(mfd uintptr) {
+		if p.Slave() == nil {
+			send(int(mfd))
+			return
+		}
+		if err := controlFile(p.Slave(), func(sfd uintptr) {
+			send(int(mfd), int(sfd))
+		}); err != nil {
+			sendErr = err
+		}
+	}); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if n < len(buf) {
+		_, err = conn.Write(buf[n:])
+	}
+
+	return err
+}
+
+// Receive receives a pseudo-terminal and its session metadata sent with Send
+// over a Unix socket, and returns it configured with the given options. Both
+// stream and message-oriented sockets, such as SOCK_SEQPACKET, are supported.
+func Receive(conn *net.UnixConn, opts ...Option) (UnixPty, *Metadata, error) {
+	stream, err := isStream(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A stream may deliver the message in pieces, so read the header first
+	// and the rest of the message afterwards. Other socket types deliver the
+	// whole message at once and discard what doesn't fit in the buffer.
+	buf := make([]byte, 4)
+	if !stream {
+		buf = make([]byte, 4+maxMetadataSize)
+	}
+	oob := make([]byte, unix.CmsgSpace(2*4))
+	n, oobn, flags, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fds []int
+	if oobn > 0 {
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return nil, nil, os.NewSyscallError("recvmsg", err)
+		}
+		for i := range msgs {
+			rights, err := unix.ParseUnixRights(&msgs[i])
+			if err == nil {
+				fds = append(fds, rights...)
+			}
+		}
+	}
+	closeFds := func() {
+		for _, fd := range fds {
+			_ = unix.Close(fd)
+		}
+	}
+
+	if flags&(unix.MSG_CTRUNC|unix.MSG_TRUNC) != 0 || len(fds) == 0 || len(fds) > 2 || (!stream && n < 4) {
+		closeFds()
+		return nil, nil, errors.New("pty: invalid pseudo-terminal message")
+	}
+
+	if stream && n < 4 {
+		if _, err := io.ReadFull(conn, buf[n:]); err != nil {
+			closeFds()
+			return nil, nil, err
+		}
+	}
+
+	size := binary.BigEndian.Uint32(buf)
+	if size > maxMetadataSize {
+		closeFds()
+		return nil, nil, fmt.Errorf("pty: metadata too large: %d bytes", size)
+	}
+
+	var data []byte
+	if stream {
+		data = make([]byte, size)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			closeFds()
+			return nil, nil, err
+		}
+	} else {
+		if uint32(n-4) != size {
+			closeFds()
+			return nil, nil, errors.New("pty: invalid pseudo-terminal message")
+		}
+		data = buf[4:n]
+	}
+
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		closeFds()
+		return nil, nil, err
+	}
+
+	slave := -1
+	if len(fds) > 1 {
+		slave = fds[1]
+	}
+	p, err := FromFd(fds[0], slave, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, &meta, nil
+}
+
+// isStream reports whether conn is a SOCK_STREAM socket.
+func isStream(conn *net.UnixConn) (bool, error) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return false, err
+	}
+
+	var typ int
+	var sockErr error
+	if err := rc.Control(func(fd uintptr) {
+		typ, sockErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_TYPE)
+	}); err != nil {
+		return false, err
+	}
+	if sockErr != nil {
+		return false, os.NewSyscallError("getsockopt", sockErr)
+	}
+
+	return typ == unix.SOCK_STREAM, nil
+}
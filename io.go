@@ -0,0 +1,59 @@
+package pty
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// aLongTimeAgo is a non-zero time, far in the past, used to unblock pending
+// reads and writes.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// ReadContext reads from the pseudo-terminal like Read, but gives up and
+// returns ctx.Err() once ctx is done. It interrupts a blocked Read by setting
+// the read deadline, and clears any read deadline afterwards if it did so.
+// This requires deadline support, see WithNonblock.
+func ReadContext(ctx context.Context, p Pty, b []byte) (int, error) {
+	return ioContext(ctx, p.SetReadDeadline, func() (int, error) {
+		return p.Read(b)
+	})
+}
+
+// WriteContext writes to the pseudo-terminal like Write, but gives up and
+// returns the number of bytes written so far and ctx.Err() once ctx is done.
+// It interrupts a blocked Write by setting the write deadline, and clears any
+// write deadline afterwards if it did so. This requires deadline support, see
+// WithNonblock.
+func WriteContext(ctx context.Context, p Pty, b []byte) (int, error) {
+	return ioContext(ctx, p.SetWriteDeadline, func() (int, error) {
+		return p.Write(b)
+	})
+}
+
+func ioContext(ctx context.Context, setDeadline func(time.Time) error, f func() (int, error)) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if ctx.Done() == nil {
+		return f()
+	}
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		_ = setDeadline(aLongTimeAgo)
+		close(interrupted)
+	})
+
+	n, err := f()
+	if !stop() {
+		<-interrupted
+		_ = setDeadline(time.Time{})
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			err = ctx.Err()
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,52 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package pty
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios      = unix.TIOCGETA
+	ioctlSetTermios      = unix.TIOCSETA
+	ioctlSetTermiosDrain = unix.TIOCSETAW
+	ioctlSetTermiosFlush = unix.TIOCSETAF
+	ioctlPacket          = unix.TIOCPKT
+	ioctlInq             = 0x4004667f // FIONREAD
+	ioctlOutq            = unix.TIOCOUTQ
+
+	flushRead  = 0x1 // FREAD
+	flushWrite = 0x2 // FWRITE
+)
+
+func termiosSpeed(u *unix.Termios) (ispeed, ospeed uint32) {
+	return uint32(u.Ispeed), uint32(u.Ospeed)
+}
+
+// setTermiosSpeed sets the speeds, ignoring zero speeds so that the current
+// ones are kept.
+func setTermiosSpeed(u *unix.Termios, ispeed, ospeed uint32) error {
+	if ispeed != 0 {
+		setUint(&u.Ispeed, ispeed)
+	}
+	if ospeed != 0 {
+		setUint(&u.Ospeed, ospeed)
+	}
+	return nil
+}
+
+func tcflush(fd int, queue int) error {
+	var which int
+	switch queue {
+	case TCIFLUSH:
+		which = flushRead
+	case TCOFLUSH:
+		which = flushWrite
+	case TCIOFLUSH:
+		which = flushRead | flushWrite
+	}
+	return unix.IoctlSetPointerInt(fd, unix.TIOCFLUSH, which)
+}
+
+func tcdrain(fd int) error {
+	return unix.IoctlSetInt(fd, unix.TIOCDRAIN, 0)
+}
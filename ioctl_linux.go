@@ -0,0 +1,102 @@
+package pty
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios      = unix.TCGETS
+	ioctlSetTermios      = unix.TCSETS
+	ioctlSetTermiosDrain = unix.TCSETSW
+	ioctlSetTermiosFlush = unix.TCSETSF
+	ioctlPacket          = unix.TIOCPKT
+	ioctlInq             = unix.TIOCINQ
+	ioctlOutq            = unix.TIOCOUTQ
+)
+
+// baudRates maps the Linux speed codes stored in the control mode flags to
+// baud rates.
+var baudRates = map[uint32]uint32{
+	unix.B0:       0,
+	unix.B50:      50,
+	unix.B75:      75,
+	unix.B110:     110,
+	unix.B134:     134,
+	unix.B150:     150,
+	unix.B200:     200,
+	unix.B300:     300,
+	unix.B600:     600,
+	unix.B1200:    1200,
+	unix.B1800:    1800,
+	unix.B2400:    2400,
+	unix.B4800:    4800,
+	unix.B9600:    9600,
+	unix.B19200:   19200,
+	unix.B38400:   38400,
+	unix.B57600:   57600,
+	unix.B115200:  115200,
+	unix.B230400:  230400,
+	unix.B460800:  460800,
+	unix.B500000:  500000,
+	unix.B576000:  576000,
+	unix.B921600:  921600,
+	unix.B1000000: 1000000,
+	unix.B1152000: 1152000,
+	unix.B1500000: 1500000,
+	unix.B2000000: 2000000,
+	unix.B2500000: 2500000,
+	unix.B3000000: 3000000,
+	unix.B3500000: 3500000,
+	unix.B4000000: 4000000,
+}
+
+// termiosSpeed returns the speeds encoded in the control mode flags, since
+// the kernel does not fill in the speed fields for TCGETS.
+func termiosSpeed(u *unix.Termios) (ispeed, ospeed uint32) {
+	ospeed = baudRates[u.Cflag&unix.CBAUD]
+	ispeed = ospeed
+	if code := (u.Cflag & unix.CIBAUD) >> unix.IBSHIFT; code != 0 {
+		ispeed = baudRates[code]
+	}
+	return ispeed, ospeed
+}
+
+// setTermiosSpeed encodes the speeds in the control mode flags. Zero speeds
+// are ignored, leaving the speed codes already in the control mode flags. It
+// returns EINVAL for speeds without a matching speed code.
+func setTermiosSpeed(u *unix.Termios, ispeed, ospeed uint32) error {
+	icode, ocode := speedCode(ispeed), speedCode(ospeed)
+	if (ispeed != 0 && icode == 0) || (ospeed != 0 && ocode == 0) {
+		return unix.EINVAL
+	}
+	if ocode != 0 {
+		u.Cflag = u.Cflag&^unix.CBAUD | ocode
+	}
+	if icode != 0 {
+		if ispeed == ospeed {
+			icode = 0
+		}
+		u.Cflag = u.Cflag&^unix.CIBAUD | icode<<unix.IBSHIFT
+	}
+	return nil
+}
+
+// speedCode returns the speed code of a non-zero baud rate, or 0 if there is
+// none.
+func speedCode(rate uint32) uint32 {
+	if rate == 0 {
+		return 0
+	}
+	for code, r := range baudRates {
+		if r == rate {
+			return code
+		}
+	}
+	return 0
+}
+
+func tcflush(fd int, queue int) error {
+	return unix.IoctlSetInt(fd, unix.TCFLSH, queue)
+}
+
+func tcdrain(fd int) error {
+	return unix.IoctlSetInt(fd, unix.TCSBRK, 1)
+}
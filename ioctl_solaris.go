@@ -0,0 +1,36 @@
+package pty
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios      = unix.TCGETS
+	ioctlSetTermios      = unix.TCSETS
+	ioctlSetTermiosDrain = unix.TCSETSW
+	ioctlSetTermiosFlush = unix.TCSETSF
+	ioctlPacket          = 0x7470     // TIOCPKT
+	ioctlInq             = 0x4004667f // FIONREAD
+	ioctlOutq            = unix.TIOCOUTQ
+)
+
+// termiosSpeed returns zero speeds on Solaris, where they are only encoded in
+// the control mode flags.
+func termiosSpeed(*unix.Termios) (ispeed, ospeed uint32) {
+	return 0, 0
+}
+
+// setTermiosSpeed returns EINVAL for non-zero speeds, which cannot be set
+// apart from the control mode flags on Solaris.
+func setTermiosSpeed(_ *unix.Termios, ispeed, ospeed uint32) error {
+	if ispeed != 0 || ospeed != 0 {
+		return unix.EINVAL
+	}
+	return nil
+}
+
+func tcflush(fd int, queue int) error {
+	return unix.IoctlSetInt(fd, unix.TCFLSH, queue)
+}
+
+func tcdrain(fd int) error {
+	return unix.IoctlSetInt(fd, unix.TCSBRK, 1)
+}
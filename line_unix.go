@@ -0,0 +1,101 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// defaultBreakDuration is the duration of a break sent with a non-positive
+// duration, as with tcsendbreak(3).
+const defaultBreakDuration = 250 * time.Millisecond
+
+// SendBreak implements UnixPty.
+func (p *unixPty) SendBreak(d time.Duration) error {
+	if d <= 0 {
+		d = defaultBreakDuration
+	}
+	return p.sendBreak(d)
+}
+
+// Flush implements UnixPty.
+func (p *unixPty) Flush(queue int) error {
+	switch queue {
+	case TCIFLUSH, TCOFLUSH, TCIOFLUSH:
+	default:
+		return unix.EINVAL
+	}
+
+	var ctrlErr error
+	if err := p.controlSlave(func(fd uintptr) {
+		ctrlErr = tcflush(int(fd), queue)
+	}); err != nil {
+		return err
+	}
+
+	return ctrlErr
+}
+
+// Drain implements UnixPty.
+func (p *unixPty) Drain() error {
+	var ctrlErr error
+	if err := p.controlSlave(func(fd uintptr) {
+		ctrlErr = tcdrain(int(fd))
+	}); err != nil {
+		return err
+	}
+
+	return ctrlErr
+}
+
+// InputQueued implements UnixPty.
+func (p *unixPty) InputQueued() (int, error) {
+	var n int
+	var ctrlErr error
+	if err := p.controlSlave(func(fd uintptr) {
+		n, ctrlErr = unix.IoctlGetInt(int(fd), ioctlInq)
+	}); err != nil {
+		return 0, err
+	}
+
+	return n, ctrlErr
+}
+
+// OutputQueued implements UnixPty.
+func (p *unixPty) OutputQueued() (int, error) {
+	var inq, outq int
+	var inErr, outErr error
+	if err := p.control(func(fd uintptr) {
+		inq, inErr = unix.IoctlGetInt(int(fd), ioctlInq)
+	}); err != nil {
+		return 0, err
+	}
+	if p.slave != nil {
+		if err := p.controlSlave(func(fd uintptr) {
+			outq, outErr = unix.IoctlGetInt(int(fd), ioctlOutq)
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if err := errors.Join(inErr, outErr); err != nil {
+		return 0, err
+	}
+
+	return inq + outq, nil
+}
+
+// ioctlMaster issues an ioctl without argument on the master end.
+func (p *unixPty) ioctlMaster(req uint) error {
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		ctrlErr = unix.IoctlSetInt(int(fd), req, 0)
+	}); err != nil {
+		return err
+	}
+
+	return ctrlErr
+}
@@ -0,0 +1,78 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd netbsd openbsd solaris
+
+package pty
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/creack/pty"
+	"golang.org/x/sys/unix"
+)
+
+// openPty allocates a pseudo-terminal pair and returns its master and slave
+// ends, and the index of the slave device parsed from its name, or -1.
+func openPty(*options) (*os.File, *os.File, int, error) {
+	master, slave, err := pty.Open()
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	return master, slave, ptsIndex(slave.Name()), nil
+}
+
+// ptsIndex returns the number at the end of the slave device name, such as 3
+// for /dev/pts/3 or /dev/ttys003, or -1.
+func ptsIndex(name string) int {
+	digits := name[len(strings.TrimRight(name, "0123456789")):]
+	index, err := strconv.Atoi(digits)
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// checkPty checks that master is the master end of a pseudo-terminal and
+// that slave, unless negative, is its slave end. It returns the name and
+// index of the slave device. Where the slave device cannot be named from the
+// master end, as on DragonFly BSD, OpenBSD and Solaris, it only checks that
+// both are terminals and returns an empty name.
+func checkPty(master, slave int, _ *options) (string, int, error) {
+	if _, err := unix.IoctlGetTermios(master, ioctlGetTermios); err != nil {
+		return "", -1, ErrNotPty
+	}
+	name, err := ptsname(master)
+	if err != nil {
+		return "", -1, ErrNotPty
+	}
+	if slave < 0 {
+		return name, ptsIndex(name), nil
+	}
+
+	if name == "" {
+		if _, err := unix.IoctlGetTermios(slave, ioctlGetTermios); err != nil {
+			return "", -1, ErrNotPty
+		}
+		return "", -1, nil
+	}
+
+	var st, nameSt unix.Stat_t
+	if err := unix.Fstat(slave, &st); err != nil {
+		return "", -1, os.NewSyscallError("fstat", err)
+	}
+	if err := unix.Stat(name, &nameSt); err != nil {
+		return "", -1, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFCHR || st.Rdev != nameSt.Rdev {
+		return "", -1, ErrNotPty
+	}
+
+	return name, ptsIndex(name), nil
+}
+
+// openPeer returns -1 as the slave end cannot be opened from the master end.
+func openPeer(int, string) (int, error) {
+	return -1, nil
+}
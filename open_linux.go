@@ -0,0 +1,141 @@
+package pty
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// defaultPtmx is the path of the pseudo-terminal multiplexer device.
+const defaultPtmx = "/dev/ptmx"
+
+// openFlags are the flags used to open both ends of a pseudo-terminal.
+const openFlags = unix.O_RDWR | unix.O_NOCTTY | unix.O_CLOEXEC
+
+// openPty allocates a pseudo-terminal pair from the multiplexer device and
+// returns its master and slave ends, and the index of the slave device.
+// The slave end is obtained from the master end with TIOCGPTPEER rather than
+// by path, so that it is the right device even when the devpts instance
+// mounted at the expected path is not the one backing the multiplexer.
+func openPty(o *options) (_, _ *os.File, _ int, retErr error) {
+	path := o.ptmxPath()
+
+	mfd, err := unix.Open(path, openFlags, 0)
+	if err != nil {
+		return nil, nil, -1, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	master := os.NewFile(uintptr(mfd), path)
+	defer func() {
+		if retErr != nil {
+			_ = master.Close()
+		}
+	}()
+
+	// Unlock the slave end, like unlockpt(3).
+	if err := unix.IoctlSetPointerInt(mfd, unix.TIOCSPTLCK, 0); err != nil {
+		return nil, nil, -1, os.NewSyscallError("unlockpt", err)
+	}
+
+	index, err := unix.IoctlGetUint32(mfd, unix.TIOCGPTN)
+	if err != nil {
+		return nil, nil, -1, os.NewSyscallError("ptsname", err)
+	}
+	name := filepath.Join(ptsDir(path), strconv.FormatUint(uint64(index), 10))
+
+	sfd, err := openPeer(mfd, name)
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	return master, os.NewFile(uintptr(sfd), name), int(index), nil
+}
+
+// checkPty checks that master is the master end of a pseudo-terminal and
+// that slave, unless negative, is its slave end. It returns the name and
+// index of the slave device, named after the multiplexer set in o.
+func checkPty(master, slave int, o *options) (string, int, error) {
+	index, err := unix.IoctlGetUint32(master, unix.TIOCGPTN)
+	if err != nil {
+		return "", -1, ErrNotPty
+	}
+	name := filepath.Join(ptsDir(o.ptmxPath()), strconv.FormatUint(uint64(index), 10))
+	if slave < 0 {
+		return name, int(index), nil
+	}
+
+	var st unix.Stat_t
+	if err := unix.Fstat(slave, &st); err != nil {
+		return "", -1, os.NewSyscallError("fstat", err)
+	}
+
+	peer, err := ioctlPeer(master, unix.O_RDONLY|unix.O_NOCTTY|unix.O_CLOEXEC)
+	if err != nil {
+		// Without TIOCGPTPEER, settle for the slave being a terminal.
+		if _, err := unix.IoctlGetTermios(slave, unix.TCGETS); err != nil {
+			return "", -1, ErrNotPty
+		}
+		return name, int(index), nil
+	}
+	defer func() {
+		_ = unix.Close(peer)
+	}()
+
+	var peerSt unix.Stat_t
+	if err := unix.Fstat(peer, &peerSt); err != nil {
+		return "", -1, os.NewSyscallError("fstat", err)
+	}
+	if st.Dev != peerSt.Dev || st.Rdev != peerSt.Rdev {
+		return "", -1, ErrNotPty
+	}
+
+	return name, int(index), nil
+}
+
+// openPeer opens the slave end of the pseudo-terminal whose master end is
+// master, falling back to opening it by name.
+func openPeer(master int, name string) (int, error) {
+	sfd, err := ioctlPeer(master, openFlags)
+	if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOTTY) {
+		// TIOCGPTPEER is only available since Linux 4.13.
+		sfd, err = unix.Open(name, openFlags, 0)
+		if err != nil {
+			return -1, &os.PathError{Op: "open", Path: name, Err: err}
+		}
+	} else if err != nil {
+		return -1, os.NewSyscallError("TIOCGPTPEER", err)
+	}
+
+	return sfd, nil
+}
+
+// ioctlPeer opens the slave end of the pseudo-terminal whose master end is
+// fd with the given open flags.
+func ioctlPeer(fd int, flags int) (int, error) {
+	sfd, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.TIOCGPTPEER, uintptr(flags))
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(sfd), nil
+}
+
+// ptmxPath returns the path of the multiplexer device to use.
+func (o *options) ptmxPath() string {
+	if o.ptmx == "" {
+		return defaultPtmx
+	}
+	return o.ptmx
+}
+
+// ptsDir returns the directory of the slave devices of the multiplexer at
+// path, which is either a sibling pts directory, as for /dev/ptmx, or the
+// directory of the multiplexer itself, as for /dev/pts/ptmx.
+func ptsDir(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == "pts" {
+		return dir
+	}
+	return filepath.Join(dir, "pts")
+}
@@ -0,0 +1,94 @@
+package pty
+
+import (
+	"golang.org/x/crypto/ssh"
+)
+
+// Option is an option that configures a pseudo-terminal created with New.
+type Option func(*options)
+
+// options holds the configuration of a pseudo-terminal being created.
+type options struct {
+	winsize  *Winsize
+	termios  *Termios
+	modes    ssh.TerminalModes
+	cloexec  bool
+	nonblock bool
+	ptmx     string
+}
+
+// newOptions returns the default options with opts applied.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		cloexec:  true,
+		nonblock: true,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithSize sets the initial size of the pseudo-terminal in columns and rows.
+func WithSize(width int, height int) Option {
+	return WithWinsize(&Winsize{
+		Row: uint16(height),
+		Col: uint16(width),
+	})
+}
+
+// WithWinsize sets the initial window size of the pseudo-terminal, including
+// the pixel dimensions. On Windows, the pixel dimensions are ignored.
+func WithWinsize(ws *Winsize) Option {
+	return func(o *options) {
+		o.winsize = ws
+	}
+}
+
+// WithTermios sets the initial line discipline settings of the
+// pseudo-terminal. Terminal modes set with WithTerminalModes are applied on
+// top of these. This has no effect on Windows.
+func WithTermios(t *Termios) Option {
+	return func(o *options) {
+		o.termios = t
+	}
+}
+
+// WithTerminalModes sets the initial terminal modes of the pseudo-terminal.
+// This has no effect on Windows.
+func WithTerminalModes(modes ssh.TerminalModes) Option {
+	return func(o *options) {
+		o.modes = modes
+	}
+}
+
+// WithCloseOnExec sets whether the pseudo-terminal file descriptors are
+// closed when the current process calls exec. The default is true. Commands
+// started with Cmd always get the slave end regardless of this option.
+// This has no effect on Windows.
+func WithCloseOnExec(cloexec bool) Option {
+	return func(o *options) {
+		o.cloexec = cloexec
+	}
+}
+
+// WithNonblock sets whether the pseudo-terminal master end is opened in
+// non-blocking mode. The default is true, which registers the master end with
+// the Go runtime poller so that reads and writes support deadlines and are
+// unblocked by Close. This has no effect on Windows.
+func WithNonblock(nonblock bool) Option {
+	return func(o *options) {
+		o.nonblock = nonblock
+	}
+}
+
+// WithPtmx sets the path of the pseudo-terminal multiplexer device used to
+// allocate the pseudo-terminal, such as the ptmx node of a container's devpts
+// mount. The default is /dev/ptmx. This only has an effect on Linux.
+func WithPtmx(path string) Option {
+	return func(o *options) {
+		o.ptmx = path
+	}
+}
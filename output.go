@@ -0,0 +1,124 @@
+package pty
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Output runs the command and returns what it wrote to the pseudo-terminal.
+// If Stderr is nil, the standard error of the command is collected
+// separately and returned in the Stderr field of the ExitError, as with
+// exec.Cmd. The output is captured as the terminal sees it, so line endings
+// are typically translated to "\r\n". Output requires the non-blocking master
+// end, see WithNonblock, and is not supported on Windows.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("pty: Stdout already set")
+	}
+	var stderr *limitedBuffer
+	if c.Stderr == nil {
+		stderr = &limitedBuffer{limit: c.OutputLimit}
+		c.Stderr = stderr
+		defer func() {
+			c.Stderr = nil
+		}()
+	}
+	out, err := c.collectOutput()
+	var ee *ExitError
+	if stderr != nil && errors.As(err, &ee) {
+		ee.Stderr = stderr.Bytes()
+	}
+	return out, err
+}
+
+// CombinedOutput runs the command and returns what it wrote to the
+// pseudo-terminal, including its standard error. The output is captured as
+// the terminal sees it, so line endings are typically translated to "\r\n".
+// CombinedOutput requires the non-blocking master end, see WithNonblock, and
+// is not supported on Windows.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("pty: Stdout already set")
+	}
+	if c.Stderr != nil {
+		return nil, errors.New("pty: Stderr already set")
+	}
+	return c.collectOutput()
+}
+
+// collectOutput runs the command while reading the pseudo-terminal until the
+// output of the command has been drained. This requires read deadlines to
+// interrupt the reader, see WithNonblock.
+func (c *Cmd) collectOutput() ([]byte, error) {
+	if _, ok := c.pty.(UnixPty); !ok {
+		return nil, ErrUnsupported
+	}
+	if err := c.pty.SetReadDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("%w: output requires read deadlines: %w", ErrUnsupported, err)
+	}
+
+	drain := c.DrainOutput
+	c.DrainOutput = true
+	defer func() {
+		c.DrainOutput = drain
+	}()
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+
+	out := &limitedBuffer{limit: c.OutputLimit}
+	copyDone := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(out, c.pty)
+		copyDone <- err
+	}()
+
+	err := c.Wait()
+	var copyErr error
+	select {
+	case copyErr = <-copyDone:
+	default:
+		// Wait returned before the output was drained, such as when the
+		// context is done, so the reader has to be interrupted.
+		_ = c.pty.SetReadDeadline(aLongTimeAgo)
+		copyErr = <-copyDone
+		_ = c.pty.SetReadDeadline(time.Time{})
+	}
+
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		ee.Output = out.Bytes()
+		return out.Bytes(), err
+	}
+	if err == nil && copyErr != nil && !errors.Is(copyErr, os.ErrDeadlineExceeded) {
+		err = copyErr
+	}
+	return out.Bytes(), err
+}
+
+// limitedBuffer is a buffer that discards what is written past limit, if
+// limit is positive. It does not embed bytes.Buffer so that io.Copy goes
+// through Write rather than ReadFrom.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write implements io.Writer. It never fails.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.limit > 0 {
+		p = p[:min(n, max(b.limit-b.buf.Len(), 0))]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// Bytes returns the buffered bytes.
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
@@ -0,0 +1,87 @@
+package pty
+
+import (
+	"io"
+)
+
+// Control bits of packets read in packet mode. See UnixPty.SetPacketMode.
+const (
+	// PacketFlushRead reports that the terminal input queue was flushed.
+	PacketFlushRead = 0x01
+
+	// PacketFlushWrite reports that the terminal output queue was flushed.
+	PacketFlushWrite = 0x02
+
+	// PacketStop reports that output to the terminal was stopped, such as
+	// with ^S.
+	PacketStop = 0x04
+
+	// PacketStart reports that output to the terminal was restarted, such as
+	// with ^Q.
+	PacketStart = 0x08
+
+	// PacketNoStop reports that the start and stop characters are no longer
+	// ^S and ^Q, or that flow control was disabled.
+	PacketNoStop = 0x10
+
+	// PacketDoStop reports that the start and stop characters are ^S and ^Q
+	// and that flow control is enabled.
+	PacketDoStop = 0x20
+
+	// PacketIoctl reports that the terminal settings were changed. This is
+	// only reported on Linux when the EXTPROC local mode flag is set.
+	PacketIoctl = 0x40
+)
+
+// maxPacketSize is the size of the buffer used to read packets. Reads from the
+// master end in packet mode never split a packet.
+const maxPacketSize = 32 * 1024
+
+// Packet is a frame read from the pseudo-terminal master end in packet mode.
+// It either holds data written to the slave end, or a combination of control
+// bits reporting a change on the slave end.
+type Packet struct {
+	// Control holds the control bits of a control packet, and is zero for a
+	// data packet.
+	Control byte
+
+	// Data holds the data of a data packet.
+	Data []byte
+}
+
+// PacketReader splits the stream read from a pseudo-terminal master end in
+// packet mode into packets. It must be the only reader of the master end.
+type PacketReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewPacketReader returns a PacketReader reading packets from r, which must be
+// a pseudo-terminal master end in packet mode.
+func NewPacketReader(r io.Reader) *PacketReader {
+	return &PacketReader{
+		r:   r,
+		buf: make([]byte, maxPacketSize),
+	}
+}
+
+// ReadPacket reads the next packet. The data of the returned packet is only
+// valid until the next call to ReadPacket.
+func (r *PacketReader) ReadPacket() (Packet, error) {
+	for {
+		n, err := r.r.Read(r.buf)
+		if n > 0 {
+			// The first byte is the status byte, which is TIOCPKT_DATA for
+			// data packets.
+			if r.buf[0] != 0 {
+				return Packet{Control: r.buf[0]}, nil
+			}
+			if n > 1 {
+				return Packet{Data: r.buf[1:n]}, nil
+			}
+		}
+		if err != nil {
+			return Packet{}, err
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package pty
+
+// ProcessInfo describes a process running on a pseudo-terminal.
+type ProcessInfo struct {
+	// Pid is the process ID.
+	Pid int
+
+	// Name is the command name of the process.
+	Name string
+
+	// Args holds the command line arguments of the process, including the
+	// command as Args[0].
+	Args []string
+
+	// Dir is the current working directory of the process.
+	Dir string
+}
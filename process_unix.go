@@ -0,0 +1,78 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// ForegroundPgrp implements UnixPty.
+func (p *unixPty) ForegroundPgrp() (int, error) {
+	var pgrp int
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		pgrp, ctrlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	}); err != nil {
+		return 0, err
+	}
+	if ctrlErr != nil {
+		return 0, ctrlErr
+	}
+	if pgrp <= 0 {
+		return 0, ErrNoForeground
+	}
+
+	return pgrp, nil
+}
+
+// ForegroundProcess implements UnixPty.
+func (p *unixPty) ForegroundProcess() (*ProcessInfo, error) {
+	pgrp, err := p.ForegroundPgrp()
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.GOOS != "linux" {
+		return &ProcessInfo{Pid: pgrp}, nil
+	}
+
+	return readProcInfo(pgrp)
+}
+
+// readProcInfo reads information about a process from the Linux /proc
+// filesystem. The command line and working directory are left empty when they
+// cannot be read, such as for processes of other users.
+func readProcInfo(pid int) (*ProcessInfo, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ProcessInfo{
+		Pid:  pid,
+		Name: string(bytes.TrimSuffix(comm, []byte("\n"))),
+	}
+
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		cmdline = bytes.TrimSuffix(cmdline, []byte{0})
+		if len(cmdline) > 0 {
+			for _, arg := range bytes.Split(cmdline, []byte{0}) {
+				info.Args = append(info.Args, string(arg))
+			}
+		}
+	}
+
+	if cwd, err := os.Readlink(filepath.Join(dir, "cwd")); err == nil {
+		info.Dir = cwd
+	}
+
+	return info, nil
+}
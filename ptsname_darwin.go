@@ -0,0 +1,19 @@
+package pty
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptsname returns the path of the slave device of the pseudo-terminal whose
+// master end is fd, like ptsname(3).
+func ptsname(fd int) (string, error) {
+	// The size of the buffer is encoded in the request.
+	buf := make([]byte, unix.TIOCPTYGNAME>>16&0x1fff)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&buf[0])))
+	if errno != 0 {
+		return "", errno
+	}
+	return unix.ByteSliceToString(buf), nil
+}
@@ -0,0 +1,33 @@
+package pty
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// fiodgnameArg is the argument of FIODGNAME, struct fiodgname_arg.
+type fiodgnameArg struct {
+	len int32
+	buf *byte
+}
+
+// ioctlFIODGNAME is _IOW('f', 120, struct fiodgname_arg).
+const ioctlFIODGNAME = 0x80000000 | unsafe.Sizeof(fiodgnameArg{})&0x1fff<<16 | 'f'<<8 | 120
+
+// ptsname returns the path of the slave device of the pseudo-terminal whose
+// master end is fd, like ptsname(3).
+func ptsname(fd int) (string, error) {
+	// FIODGNAME names any device, so check that fd is a master end first.
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.TIOCPTMASTER, 0); errno != 0 {
+		return "", errno
+	}
+
+	buf := make([]byte, 256)
+	arg := fiodgnameArg{len: int32(len(buf)), buf: &buf[0]}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), ioctlFIODGNAME, uintptr(unsafe.Pointer(&arg)))
+	if errno != 0 {
+		return "", errno
+	}
+	return "/dev/" + unix.ByteSliceToString(buf), nil
+}
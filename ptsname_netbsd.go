@@ -0,0 +1,15 @@
+package pty
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// ptsname returns the path of the slave device of the pseudo-terminal whose
+// master end is fd, like ptsname(3).
+func ptsname(fd int) (string, error) {
+	ptm, err := unix.IoctlGetPtmget(fd, unix.TIOCPTSNAME)
+	if err != nil {
+		return "", err
+	}
+	return unix.ByteSliceToString(ptm.Sn[:]), nil
+}
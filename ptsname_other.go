@@ -0,0 +1,10 @@
+//go:build dragonfly || openbsd || solaris
+// +build dragonfly openbsd solaris
+
+package pty
+
+// ptsname returns an empty name as the slave device cannot be named from the
+// master end on this system.
+func ptsname(int) (string, error) {
+	return "", nil
+}
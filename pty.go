@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 )
 
 var (
@@ -13,14 +14,47 @@ var (
 
 	// ErrUnsupported is returned when the platform is unsupported.
 	ErrUnsupported = errors.New("pty: unsupported platform")
+
+	// ErrClosed is returned when using a closed pseudo-terminal.
+	ErrClosed = errors.New("pty: pseudo-terminal is closed")
+
+	// ErrNotPty is returned when files are not the two ends of a
+	// pseudo-terminal.
+	ErrNotPty = errors.New("pty: not a pseudo-terminal")
+
+	// ErrNoForeground is returned when the pseudo-terminal has no foreground
+	// process group.
+	ErrNoForeground = errors.New("pty: no foreground process group")
 )
 
-// New returns a new pseudo-terminal.
-func New() (Pty, error) {
-	return newPty()
+// New returns a new pseudo-terminal configured with the given options.
+func New(opts ...Option) (Pty, error) {
+	return newPty(newOptions(opts...))
+}
+
+// FromFiles returns a pseudo-terminal for existing master and slave ends,
+// such as ones received from another process, configured with the given
+// options. The slave end is optional: on Linux, it is opened from the master
+// end when nil, while on other platforms, the operations that need it fail.
+// On Linux, macOS, FreeBSD and NetBSD, the files are checked to be the two
+// ends of the same pseudo-terminal; on other platforms, they are only checked
+// to be terminals. On Linux, the slave end is named after the multiplexer set
+// with WithPtmx.
+//
+// FromFiles takes ownership of the files, and closes them on error. Use
+// Master and Slave to access the ends afterwards.
+func FromFiles(master, slave *os.File, opts ...Option) (UnixPty, error) {
+	return fromFiles(master, slave, newOptions(opts...))
+}
+
+// FromFd is like FromFiles, but takes file descriptors. A negative slave
+// means there is no slave end.
+func FromFd(master, slave int, opts ...Option) (UnixPty, error) {
+	return fromFd(master, slave, newOptions(opts...))
 }
 
 // Pty is a pseudo-terminal interface.
+// It is safe for concurrent use. Once closed, its methods return ErrClosed.
 type Pty interface {
 	io.ReadWriteCloser
 
@@ -30,6 +64,10 @@ type Pty interface {
 	// pseudo-terminal TTY.
 	Name() string
 
+	// Done returns a channel that is closed when the pseudo-terminal is
+	// closed.
+	Done() <-chan struct{}
+
 	// Command returns a command that can be used to start a process
 	// attached to the pseudo-terminal.
 	Command(name string, args ...string) *Cmd
@@ -41,10 +79,37 @@ type Pty interface {
 	// Resize resizes the pseudo-terminal.
 	Resize(width int, height int) error
 
+	// ResizeWithPixels resizes the pseudo-terminal and sets the size of the
+	// window in pixels, which programs use to compute the size of a cell for
+	// graphics. On Windows, the pixel dimensions are ignored.
+	ResizeWithPixels(width int, height int, xpixel int, ypixel int) error
+
+	// Size returns the current size of the pseudo-terminal in columns and
+	// rows. On Unix, this is read from the kernel, so it reflects changes
+	// made by the process running on the pseudo-terminal.
+	Size() (width int, height int, err error)
+
 	// Fd returns the file descriptor of the pseudo-terminal.
 	// On Unix, this will return the file descriptor of the master end.
+	// Unlike os.File.Fd, this does not put the master end in blocking mode.
 	// On Windows, this will return the handle of the console.
 	Fd() uintptr
+
+	// SetDeadline sets the read and write deadlines of the pseudo-terminal.
+	// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+	// On Windows, deadlines are not supported and os.ErrNoDeadline is
+	// returned.
+	SetDeadline(t time.Time) error
+
+	// SetReadDeadline sets the deadline for future Read calls and any
+	// currently-blocked Read call. A zero value for t means Read will not time
+	// out.
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline sets the deadline for future Write calls and any
+	// currently-blocked Write call. A zero value for t means Write will not
+	// time out.
+	SetWriteDeadline(t time.Time) error
 }
 
 // UnixPty is a Unix pseudo-terminal interface.
@@ -54,14 +119,116 @@ type UnixPty interface {
 	// Master returns the pseudo-terminal master end (pty).
 	Master() *os.File
 
-	// Slave returns the pseudo-terminal slave end (tty).
+	// Slave returns the pseudo-terminal slave end (tty). It returns nil if
+	// the pseudo-terminal was created from a master end alone and the slave
+	// end could not be opened.
 	Slave() *os.File
 
+	// Index returns the index of the slave device, such as 3 for /dev/pts/3,
+	// or -1 if it is unknown.
+	Index() int
+
 	// Control calls f on the pseudo-terminal master end (pty).
 	Control(f func(fd uintptr)) error
 
 	// SetWinsize sets the pseudo-terminal window size.
 	SetWinsize(ws *Winsize) error
+
+	// Winsize returns the current pseudo-terminal window size, including the
+	// pixel dimensions.
+	Winsize() (*Winsize, error)
+
+	// WatchSize returns a channel that receives the window size every time
+	// it changes, whether through Resize and SetWinsize or by a process
+	// holding the slave end. Changes made by other processes are detected by
+	// polling, so they are reported with a short delay, and changes that are
+	// undone before being detected are not reported. The channel is closed
+	// when ctx is done or the pseudo-terminal is closed.
+	WatchSize(ctx context.Context) <-chan Winsize
+
+	// GetAttr returns the line discipline settings of the pseudo-terminal.
+	GetAttr() (*Termios, error)
+
+	// SetAttr sets the line discipline settings of the pseudo-terminal.
+	// The when argument is one of TCSANOW, TCSADRAIN or TCSAFLUSH.
+	SetAttr(when int, t *Termios) error
+
+	// MakeRaw puts the pseudo-terminal in raw mode, where input is passed
+	// through byte by byte without echo or special processing, and returns
+	// the previous state.
+	MakeRaw() (*State, error)
+
+	// MakeCbreak puts the pseudo-terminal in cbreak mode, where input is
+	// available byte by byte without echo but signal characters are still
+	// processed, and returns the previous state.
+	MakeCbreak() (*State, error)
+
+	// DisableEcho disables echoing of input and returns the previous state.
+	DisableEcho() (*State, error)
+
+	// Restore restores the pseudo-terminal to a previous state.
+	Restore(state *State) error
+
+	// SetPacketMode enables or disables packet mode on the master end.
+	// In packet mode, every read from the master end returns either data
+	// written to the slave end or control bits reporting flow control and
+	// queue flush events. Use a PacketReader to read packets.
+	SetPacketMode(enable bool) error
+
+	// ForegroundPgrp returns the ID of the foreground process group of the
+	// pseudo-terminal, which is the job a shell running on it is waiting on.
+	ForegroundPgrp() (int, error)
+
+	// ForegroundProcess returns information about the leader of the
+	// foreground process group of the pseudo-terminal. The command name,
+	// arguments and working directory are only reported on Linux.
+	ForegroundProcess() (*ProcessInfo, error)
+
+	// Signal sends a signal to the foreground process group of the
+	// pseudo-terminal, like the line discipline does for signal characters.
+	Signal(sig os.Signal) error
+
+	// Interrupt sends SIGINT to the foreground process group.
+	Interrupt() error
+
+	// Suspend sends SIGTSTP to the foreground process group.
+	Suspend() error
+
+	// Hangup sends SIGHUP to the foreground process group.
+	Hangup() error
+
+	// SendBreak sends a break condition for the given duration, or for 250
+	// milliseconds if d is not positive. It returns ErrUnsupported on Linux,
+	// where pseudo-terminals accept but ignore break conditions.
+	SendBreak(d time.Duration) error
+
+	// Flush discards data written to the pseudo-terminal but not yet read by
+	// the process, data written by the process but not yet read from the
+	// master end, or both. The queue argument is one of TCIFLUSH, TCOFLUSH or
+	// TCIOFLUSH.
+	Flush(queue int) error
+
+	// Drain waits until all output written by the process has been
+	// transmitted to the master end.
+	Drain() error
+
+	// InputQueued returns the number of bytes written to the pseudo-terminal
+	// that the process has not read yet.
+	InputQueued() (int, error)
+
+	// OutputQueued returns the number of bytes written by the process that
+	// have not been read from the master end yet.
+	OutputQueued() (int, error)
+
+	// Reset prepares the pseudo-terminal for the next command once the
+	// previous one has exited. It hangs up the processes still in the
+	// foreground of the terminal and their session, and waits until the
+	// session has given up the terminal, so that another command can make it
+	// its controlling terminal, or until ctx is done. It then discards
+	// pending input and output, restores the line discipline settings the
+	// pseudo-terminal was created with, and makes reads block for new output
+	// again.
+	Reset(ctx context.Context) error
 }
 
 // ConPty is a Windows ConPTY interface.
@@ -3,6 +3,16 @@
 
 package pty
 
-func newPty() (Pty, error) {
+import "os"
+
+func newPty(*options) (Pty, error) {
+	return nil, ErrUnsupported
+}
+
+func fromFiles(*os.File, *os.File, *options) (UnixPty, error) {
+	return nil, ErrUnsupported
+}
+
+func fromFd(int, int, *options) (UnixPty, error) {
 	return nil, ErrUnsupported
 }
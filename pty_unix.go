@@ -6,30 +6,89 @@ package pty
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
-	"github.com/creack/pty"
 	"golang.org/x/sys/unix"
 )
 
+var errNoSlave = errors.New("pty: no slave end")
+
 // unixPty is a POSIX compliant Unix pseudo-terminal.
 // See: https://pubs.opengroup.org/onlinepubs/9699919799/
 type unixPty struct {
 	master, slave *os.File
-	closed        bool
+	name          string
+	index         int
+
+	// closed is set by Close, and done is closed then. Methods using the
+	// file descriptors don't need to hold a lock, as the os.File methods keep
+	// a descriptor open until the calls using it return.
+	closed atomic.Bool
+	done   chan struct{}
+
+	// mu serializes Close with changes to the slave end ownership.
+	mu sync.Mutex
+
+	// owner is the ownership of the slave end before a command was started
+	// as another user, which Close restores. It is guarded by mu.
+	owner *ttyOwner
+
+	// initial holds the line discipline settings the pseudo-terminal was
+	// set up with, which Reset restores.
+	initial *Termios
+
+	// eof is set once the output of the last command has been drained, and
+	// makes reads return io.EOF until the next command starts.
+	eof atomic.Bool
+
+	// watchers are notified when the window size is set, see WatchSize.
+	watchers   map[chan struct{}]struct{}
+	watchersMu sync.Mutex
 }
 
 var _ Pty = &unixPty{}
 
 // Close implements Pty.
 func (p *unixPty) Close() error {
-	if p.closed {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed.CompareAndSwap(false, true) {
 		return nil
 	}
-	defer func() {
-		p.closed = true
-	}()
-	return errors.Join(p.master.Close(), p.slave.Close())
+	close(p.done)
+
+	var ownerErr, slaveErr error
+	if p.slave != nil {
+		if p.owner != nil {
+			ownerErr = p.owner.restore(p.slave)
+		}
+		slaveErr = p.slave.Close()
+	}
+	return errors.Join(ownerErr, p.master.Close(), slaveErr)
+}
+
+// Done implements Pty.
+func (p *unixPty) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *unixPty) isClosed() bool {
+	return p.closed.Load()
+}
+
+// closedErr returns ErrClosed in place of err if the pseudo-terminal is
+// closed.
+func (p *unixPty) closedErr(err error) error {
+	if err != nil && p.isClosed() {
+		return ErrClosed
+	}
+	return err
 }
 
 // Command implements Pty.
@@ -51,12 +110,36 @@ func (p *unixPty) CommandContext(ctx context.Context, name string, args ...strin
 
 // Name implements Pty.
 func (p *unixPty) Name() string {
-	return p.slave.Name()
+	return p.name
+}
+
+// Index implements UnixPty.
+func (p *unixPty) Index() int {
+	return p.index
 }
 
 // Read implements Pty.
+// It returns io.EOF once the slave end has been hung up, which Linux reports
+// as EIO, or once the output of a command started with DrainOutput has been
+// drained.
 func (p *unixPty) Read(b []byte) (n int, err error) {
-	return p.master.Read(b)
+	if p.isClosed() {
+		return 0, ErrClosed
+	}
+	if p.eof.Load() {
+		return 0, io.EOF
+	}
+	n, err = p.master.Read(b)
+	if err != nil {
+		if p.isClosed() {
+			err = ErrClosed
+		} else if errors.Is(err, syscall.EIO) {
+			err = io.EOF
+		} else if p.eof.Load() && errors.Is(err, os.ErrDeadlineExceeded) {
+			err = io.EOF
+		}
+	}
+	return n, err
 }
 
 // Control implements UnixPty.
@@ -65,7 +148,24 @@ func (p *unixPty) Control(f func(fd uintptr)) error {
 }
 
 func (p *unixPty) control(f func(fd uintptr)) error {
-	conn, err := p.master.SyscallConn()
+	if p.isClosed() {
+		return ErrClosed
+	}
+	return p.closedErr(controlFile(p.master, f))
+}
+
+func (p *unixPty) controlSlave(f func(fd uintptr)) error {
+	if p.isClosed() {
+		return ErrClosed
+	}
+	if p.slave == nil {
+		return errNoSlave
+	}
+	return p.closedErr(controlFile(p.slave, f))
+}
+
+func controlFile(file *os.File, f func(fd uintptr)) error {
+	conn, err := file.SyscallConn()
 	if err != nil {
 		return err
 	}
@@ -82,6 +182,23 @@ func (p *unixPty) Slave() *os.File {
 	return p.slave
 }
 
+// SetPacketMode implements UnixPty.
+func (p *unixPty) SetPacketMode(enable bool) error {
+	var mode int
+	if enable {
+		mode = 1
+	}
+
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		ctrlErr = unix.IoctlSetPointerInt(int(fd), ioctlPacket, mode)
+	}); err != nil {
+		return err
+	}
+
+	return ctrlErr
+}
+
 // Winsize represents the terminal window size.
 type Winsize = unix.Winsize
 
@@ -93,8 +210,34 @@ func (p *unixPty) SetWinsize(ws *Winsize) error {
 	}); err != nil {
 		return err
 	}
+	if ctrlErr != nil {
+		return ctrlErr
+	}
 
-	return ctrlErr
+	p.notifyWatchers()
+	return nil
+}
+
+// Winsize implements UnixPty.
+func (p *unixPty) Winsize() (*Winsize, error) {
+	var ws *Winsize
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		ws, ctrlErr = unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	}); err != nil {
+		return nil, err
+	}
+
+	return ws, ctrlErr
+}
+
+// Size implements Pty.
+func (p *unixPty) Size() (width int, height int, err error) {
+	ws, err := p.Winsize()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
 }
 
 // Resize implements Pty.
@@ -105,24 +248,306 @@ func (p *unixPty) Resize(width int, height int) error {
 	})
 }
 
+// ResizeWithPixels implements Pty.
+func (p *unixPty) ResizeWithPixels(width int, height int, xpixel int, ypixel int) error {
+	return p.SetWinsize(&Winsize{
+		Row:    uint16(height),
+		Col:    uint16(width),
+		Xpixel: uint16(xpixel),
+		Ypixel: uint16(ypixel),
+	})
+}
+
 // Write implements Pty.
 func (p *unixPty) Write(b []byte) (n int, err error) {
-	return p.master.Write(b)
+	if p.isClosed() {
+		return 0, ErrClosed
+	}
+	n, err = p.master.Write(b)
+	return n, p.closedErr(err)
 }
 
 // Fd implements Pty.
 func (p *unixPty) Fd() uintptr {
-	return p.master.Fd()
+	fd := ^uintptr(0)
+	_ = p.control(func(u uintptr) {
+		fd = u
+	})
+	return fd
+}
+
+// SetDeadline implements Pty.
+func (p *unixPty) SetDeadline(t time.Time) error {
+	return p.closedErr(p.master.SetDeadline(t))
+}
+
+// SetReadDeadline implements Pty.
+func (p *unixPty) SetReadDeadline(t time.Time) error {
+	return p.closedErr(p.master.SetReadDeadline(t))
 }
 
-func newPty() (UnixPty, error) {
-	master, slave, err := pty.Open()
+// SetWriteDeadline implements Pty.
+func (p *unixPty) SetWriteDeadline(t time.Time) error {
+	return p.closedErr(p.master.SetWriteDeadline(t))
+}
+
+func newPty(o *options) (UnixPty, error) {
+	master, slave, index, err := openPty(o)
 	if err != nil {
 		return nil, err
 	}
 
-	return &unixPty{
+	return newUnixPty(master, slave, slave.Name(), index, o)
+}
+
+func fromFiles(master, slave *os.File, o *options) (UnixPty, error) {
+	closeFiles := func() {
+		_ = master.Close()
+		if slave != nil {
+			_ = slave.Close()
+		}
+	}
+
+	var name string
+	var index int
+	var checkErr error
+	if err := controlFile(master, func(mfd uintptr) {
+		if slave == nil {
+			name, index, checkErr = checkPty(int(mfd), -1, o)
+			return
+		}
+		if err := controlFile(slave, func(sfd uintptr) {
+			name, index, checkErr = checkPty(int(mfd), int(sfd), o)
+		}); err != nil {
+			checkErr = err
+		}
+	}); err != nil {
+		closeFiles()
+		return nil, err
+	}
+	if checkErr != nil {
+		closeFiles()
+		return nil, checkErr
+	}
+
+	if slave == nil {
+		var sfd int
+		var openErr error
+		if err := controlFile(master, func(mfd uintptr) {
+			sfd, openErr = openPeer(int(mfd), name)
+		}); err != nil {
+			closeFiles()
+			return nil, err
+		}
+		if openErr != nil {
+			closeFiles()
+			return nil, openErr
+		}
+		if sfd >= 0 {
+			slave = os.NewFile(uintptr(sfd), name)
+		}
+	}
+	if name == "" && slave != nil {
+		name = slave.Name()
+	}
+
+	return newUnixPty(master, slave, name, index, o)
+}
+
+func fromFd(master, slave int, o *options) (UnixPty, error) {
+	// fromFiles checks the pair and names the pseudo-terminal.
+	var sf *os.File
+	if slave >= 0 {
+		sf = os.NewFile(uintptr(slave), "")
+	}
+
+	return fromFiles(os.NewFile(uintptr(master), "/dev/ptmx"), sf, o)
+}
+
+// newUnixPty returns a pseudo-terminal for the given ends, with the options
+// applied. The ends are closed on error.
+func newUnixPty(master, slave *os.File, name string, index int, o *options) (_ UnixPty, retErr error) {
+	p := &unixPty{
 		master: master,
 		slave:  slave,
-	}, nil
+		name:   name,
+		index:  index,
+		done:   make(chan struct{}),
+	}
+	defer func() {
+		if retErr != nil {
+			_ = p.Close()
+		}
+	}()
+
+	if err := p.setup(o); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// setup applies the options to the pseudo-terminal before it is handed to
+// the caller.
+func (p *unixPty) setup(o *options) error {
+	master, err := reopenFile(p.master, o.nonblock)
+	if err != nil {
+		return err
+	}
+	p.master = master
+
+	if !o.cloexec {
+		for _, f := range []*os.File{p.master, p.slave} {
+			if f == nil {
+				continue
+			}
+			if err := setCloseOnExec(f, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.termios != nil {
+		if err := p.SetAttr(TCSANOW, o.termios); err != nil {
+			return err
+		}
+	}
+
+	if o.modes != nil {
+		ws := o.winsize
+		if ws == nil {
+			ws, err = p.Winsize()
+			if err != nil {
+				return err
+			}
+		}
+
+		var modesErr error
+		if err := p.control(func(fd uintptr) {
+			modesErr = applyTerminalModesToFd(int(fd), ws, o.modes)
+		}); err != nil {
+			return err
+		}
+		if modesErr != nil {
+			return modesErr
+		}
+	}
+
+	if o.winsize != nil {
+		if err := p.SetWinsize(o.winsize); err != nil {
+			return err
+		}
+	}
+
+	p.initial, err = p.GetAttr()
+	return err
+}
+
+// drainInterval is the interval at which drainOutput polls the
+// pseudo-terminal queues.
+const drainInterval = 10 * time.Millisecond
+
+// drainOutput waits until all the output written to the slave end has been read
+// from the master end, then makes further reads return io.EOF until resume is
+// called. The queues must be found empty twice in a row, since the kernel
+// may still be moving the last bytes written by an exited process to the
+// master end.
+func (p *unixPty) drainOutput(ctx context.Context) error {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for empty := 0; empty < 2; {
+		n, err := p.OutputQueued()
+		if errors.Is(err, ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			empty++
+		} else {
+			empty = 0
+		}
+
+		select {
+		case <-done:
+			return ctx.Err()
+		case <-p.done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+
+	p.eof.Store(true)
+	if err := p.master.SetReadDeadline(aLongTimeAgo); err != nil {
+		// A blocking master has no deadlines, so a pending read cannot be
+		// interrupted. Later reads still return io.EOF.
+		return fmt.Errorf("pty: cannot interrupt reads after draining output: %w", err)
+	}
+	return nil
+}
+
+// resume undoes drainOutput, making reads block for new output again.
+func (p *unixPty) resume() {
+	if p.eof.Swap(false) {
+		_ = p.master.SetReadDeadline(time.Time{})
+	}
+}
+
+// reopenFile returns a new file for the file descriptor of f in the given
+// blocking mode and closes f. A file created from a non-blocking file
+// descriptor is registered with the Go runtime poller.
+func reopenFile(f *os.File, nonblock bool) (*os.File, error) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var nfd int
+	var ctrlErr error
+	if err := conn.Control(func(fd uintptr) {
+		nfd, ctrlErr = unix.FcntlInt(fd, unix.F_DUPFD_CLOEXEC, 0)
+	}); err != nil {
+		return nil, err
+	}
+	if ctrlErr != nil {
+		return nil, ctrlErr
+	}
+
+	if err := unix.SetNonblock(nfd, nonblock); err != nil {
+		_ = unix.Close(nfd)
+		return nil, err
+	}
+
+	nf := os.NewFile(uintptr(nfd), f.Name())
+	_ = f.Close()
+	return nf, nil
+}
+
+// setCloseOnExec sets or clears the close-on-exec flag of f.
+func setCloseOnExec(f *os.File, cloexec bool) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var flag int
+	if cloexec {
+		flag = unix.FD_CLOEXEC
+	}
+
+	var ctrlErr error
+	if err := conn.Control(func(fd uintptr) {
+		_, ctrlErr = unix.FcntlInt(fd, unix.F_SETFD, flag)
+	}); err != nil {
+		return err
+	}
+
+	return ctrlErr
 }
@@ -0,0 +1,472 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/sys/unix"
+)
+
+func TestFromFilesMismatchedPair(t *testing.T) {
+	switch runtime.GOOS {
+	case "darwin", "freebsd", "linux", "netbsd":
+	default:
+		t.Skip("pairs cannot be checked on this system")
+	}
+
+	a, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	master, err := dupFile(a.(UnixPty).Master())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slave, err := dupFile(b.(UnixPty).Slave())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := FromFiles(master, slave)
+	if !errors.Is(err, ErrNotPty) {
+		if p != nil {
+			p.Close()
+		}
+		t.Fatalf("FromFiles with a mismatched pair: got error %v, want %v", err, ErrNotPty)
+	}
+}
+
+func TestFromFilesNotPty(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	p, err := FromFiles(r, nil)
+	if !errors.Is(err, ErrNotPty) {
+		if p != nil {
+			p.Close()
+		}
+		t.Fatalf("FromFiles with a pipe: got error %v, want %v", err, ErrNotPty)
+	}
+}
+
+// dupFile returns a new file for a duplicate of the file descriptor of f, as
+// FromFiles takes ownership of the files it is given.
+func dupFile(f *os.File) (*os.File, error) {
+	var nfd int
+	var dupErr error
+	if err := controlFile(f, func(fd uintptr) {
+		nfd, dupErr = unix.FcntlInt(fd, unix.F_DUPFD_CLOEXEC, 0)
+	}); err != nil {
+		return nil, err
+	}
+	if dupErr != nil {
+		return nil, dupErr
+	}
+	return os.NewFile(uintptr(nfd), f.Name()), nil
+}
+
+func TestSendBreakLinux(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only Linux pseudo-terminals ignore breaks")
+	}
+
+	p := newTestPty(t)
+	if err := p.SendBreak(time.Millisecond); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("SendBreak: got error %v, want %v", err, ErrUnsupported)
+	}
+}
+
+// newTestPty returns a new pseudo-terminal, closed when the test ends.
+func newTestPty(t *testing.T, opts ...Option) UnixPty {
+	t.Helper()
+	p, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = p.Close()
+	})
+	return p.(UnixPty)
+}
+
+func TestCloseWithPendingCalls(t *testing.T) {
+	p := newTestPty(t)
+
+	// Fill the output queue so that Drain blocks where the kernel waits for
+	// the master end to be read.
+	_, _ = p.Slave().Write(bytes.Repeat([]byte("x"), 1024))
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// Read until Close makes Read fail, so that it is pending when
+		// Close is called.
+		b := make([]byte, 4096)
+		for {
+			if _, err := p.Read(b); err != nil {
+				break
+			}
+		}
+		done <- struct{}{}
+	}()
+	go func() {
+		_ = p.Drain()
+		done <- struct{}{}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- p.Close()
+	}()
+	for _, ch := range []chan struct{}{done, done} {
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("pending calls did not return after Close")
+		}
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	if _, err := p.Read(make([]byte, 1)); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Read after Close: got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestSetAttrZeroSpeeds(t *testing.T) {
+	p := newTestPty(t)
+
+	want, err := p.GetAttr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Ospeed == 0 {
+		t.Skip("speeds are not reported on this system")
+	}
+
+	// Zero speeds keep the current ones rather than hanging up the line.
+	tt := *want
+	tt.Ispeed, tt.Ospeed = 0, 0
+	if err := p.SetAttr(TCSANOW, &tt); err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.GetAttr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Ispeed != want.Ispeed || got.Ospeed != want.Ospeed {
+		t.Fatalf("speeds after setting zero speeds: got %d/%d, want %d/%d",
+			got.Ispeed, got.Ospeed, want.Ispeed, want.Ospeed)
+	}
+}
+
+func TestSetAttrInvalidSpeed(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only Linux restricts speeds to speed codes")
+	}
+
+	p := newTestPty(t)
+	tt, err := p.GetAttr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt.Ospeed = 12345
+	if err := p.SetAttr(TCSANOW, tt); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("SetAttr with speed 12345: got error %v, want %v", err, unix.EINVAL)
+	}
+}
+
+func TestDrainOutputBlockingMaster(t *testing.T) {
+	p := newTestPty(t, WithNonblock(false))
+
+	c := p.Command("true")
+	c.DrainOutput = true
+	if err := c.Start(); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Start: got error %v, want %v", err, ErrUnsupported)
+	}
+	if c.Process != nil {
+		t.Fatal("Start started the process")
+	}
+}
+
+func TestFromFilesPtmxName(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("WithPtmx only has an effect on Linux")
+	}
+
+	a := newTestPty(t)
+	master, err := dupFile(a.Master())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := FromFiles(master, nil, WithPtmx("/mnt/pts/ptmx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if want := filepath.Join("/mnt/pts", filepath.Base(a.Name())); p.Name() != want {
+		t.Fatalf("Name: got %q, want %q", p.Name(), want)
+	}
+}
+
+func TestApplyTerminalModesNilWinsize(t *testing.T) {
+	p := newTestPty(t, WithSize(100, 30))
+
+	var err error
+	if ctrlErr := p.Control(func(fd uintptr) {
+		err = ApplyTerminalModesWinsize(int(fd), nil, ssh.TerminalModes{ssh.ECHO: 0})
+	}); ctrlErr != nil {
+		t.Fatal(ctrlErr)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := p.Winsize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Col != 100 || ws.Row != 30 {
+		t.Fatalf("size after applying modes: got %dx%d, want 100x30", ws.Col, ws.Row)
+	}
+}
+
+func TestCommandCallerCtty(t *testing.T) {
+	p := newTestPty(t)
+
+	// The caller passes the slave as file descriptor 3 and makes it the
+	// controlling terminal, so it must not be passed again as 4.
+	c := p.Command("sh", "-c", "[ ! -e /dev/fd/4 ]")
+	c.Stdin = bytes.NewReader(nil)
+	c.ExtraFiles = []*os.File{p.Slave()}
+	c.SysProcAttr = &unix.SysProcAttr{Ctty: 3}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookupShell(t *testing.T) {
+	entries := "root:x:0:0:root:/root:/bin/bash\nnoshell:x:1:1::/:\n"
+	if shell, ok := passwdShell(entries, "root"); !ok || shell != "/bin/bash" {
+		t.Fatalf("passwdShell(root): got %q, %v, want /bin/bash, true", shell, ok)
+	}
+	if _, ok := passwdShell(entries, "noshell"); ok {
+		t.Fatal("passwdShell(noshell): got a shell for an entry without one")
+	}
+	if shell := lookupShell("pty-test-no-such-user"); shell != defaultShell {
+		t.Fatalf("lookupShell of a missing user: got %q, want %q", shell, defaultShell)
+	}
+}
+
+func TestStartFailureRestoresOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the slave ownership requires root")
+	}
+	p := newTestPty(t)
+
+	want, err := p.Slave().Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := p.Command("/nonexistent/pty-test")
+	c.User = "nobody"
+	if err := c.Start(); err == nil {
+		t.Fatal("Start of a missing program succeeded")
+	}
+
+	got, err := p.Slave().Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotSt, wantSt := got.Sys().(*syscall.Stat_t), want.Sys().(*syscall.Stat_t)
+	if gotSt.Uid != wantSt.Uid || gotSt.Gid != wantSt.Gid || got.Mode() != want.Mode() {
+		t.Fatalf("slave after a failed Start: got %d:%d %v, want %d:%d %v",
+			gotSt.Uid, gotSt.Gid, got.Mode(), wantSt.Uid, wantSt.Gid, want.Mode())
+	}
+}
+
+// unixConnPair returns a connected pair of Unix sockets of the given type.
+func unixConnPair(t *testing.T, typ int) (*net.UnixConn, *net.UnixConn) {
+	t.Helper()
+	fds, err := unix.Socketpair(unix.AF_UNIX, typ, 0)
+	if err != nil {
+		t.Skipf("socketpair: %v", err)
+	}
+
+	var conns [2]*net.UnixConn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		c, err := net.FileConn(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = c.Close()
+		})
+		conns[i] = c.(*net.UnixConn)
+	}
+	return conns[0], conns[1]
+}
+
+func TestSendReceive(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		typ  int
+	}{
+		{"stream", unix.SOCK_STREAM},
+		{"seqpacket", unix.SOCK_SEQPACKET},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPty(t, WithSize(100, 30))
+			a, b := unixConnPair(t, tt.typ)
+
+			meta := &Metadata{Pid: 42, Data: bytes.Repeat([]byte("x"), 4096)}
+			if err := Send(a, p, meta); err != nil {
+				t.Fatal(err)
+			}
+			q, got, err := Receive(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer q.Close()
+
+			if got.Pid != meta.Pid || !bytes.Equal(got.Data, meta.Data) {
+				t.Fatalf("Receive: got pid %d and %d bytes of data, want pid %d and %d bytes",
+					got.Pid, len(got.Data), meta.Pid, len(meta.Data))
+			}
+			if got.Winsize.Col != 100 || got.Winsize.Row != 30 {
+				t.Fatalf("Receive: got size %dx%d, want 100x30", got.Winsize.Col, got.Winsize.Row)
+			}
+
+			// The received master end is the same pseudo-terminal.
+			if _, err := p.Slave().Write([]byte("ping\n")); err != nil {
+				t.Fatal(err)
+			}
+			buf := make([]byte, 16)
+			_ = q.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, err := q.Read(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(buf[:n], []byte("ping")) {
+				t.Fatalf("Read from the received master: got %q, want %q", buf[:n], "ping")
+			}
+		})
+	}
+}
+
+func TestOutputLeavesCmdUnchanged(t *testing.T) {
+	p := newTestPty(t)
+
+	c := p.Command("sh", "-c", "echo out; echo err >&2")
+	out, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("out")) || bytes.Contains(out, []byte("err")) {
+		t.Fatalf("Output: got %q, want the standard output only", out)
+	}
+	if c.DrainOutput || c.Stdout != nil || c.Stderr != nil {
+		t.Fatalf("Output changed the command: DrainOutput %v, Stdout %v, Stderr %v",
+			c.DrainOutput, c.Stdout, c.Stderr)
+	}
+
+	// A blocking master is rejected before the command is started.
+	q := newTestPty(t, WithNonblock(false))
+	c = q.Command("true")
+	if _, err := c.Output(); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Output on a blocking master: got error %v, want %v", err, ErrUnsupported)
+	}
+	if c.Process != nil || c.DrainOutput || c.Stderr != nil {
+		t.Fatal("Output on a blocking master changed the command")
+	}
+}
+
+func TestResetThenStart(t *testing.T) {
+	p := newTestPty(t)
+
+	// The first session ignores the hangup for a while, so Reset has to wait
+	// for it to release the terminal.
+	c := p.Command("sh", "-c", "trap '' HUP; echo ready; sleep 0.2")
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	_ = p.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var out []byte
+	for buf := make([]byte, 64); !bytes.Contains(out, []byte("ready")); {
+		n, err := p.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, buf[:n]...)
+	}
+	_ = p.SetReadDeadline(time.Time{})
+	waitDone := make(chan error, 1)
+	go func() {
+		waitDone <- c.Wait()
+	}()
+
+	tt, err := p.GetAttr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo := tt.Lflag & unix.ECHO
+	tt.Lflag ^= unix.ECHO
+	if err := p.SetAttr(TCSANOW, tt); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-waitDone:
+	default:
+		t.Fatal("Reset returned before the first session exited")
+	}
+	if tt, err := p.GetAttr(); err != nil {
+		t.Fatal(err)
+	} else if tt.Lflag&unix.ECHO != echo {
+		t.Fatal("Reset did not restore the initial terminal settings")
+	}
+
+	out, err = p.Command("echo", "second").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("second")) {
+		t.Fatalf("Output of the second command: got %q, want it to contain %q", out, "second")
+	}
+}
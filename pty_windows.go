@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
@@ -19,8 +20,7 @@ const (
 )
 
 var (
-	errClosedConPty = errors.New("pseudo console is closed")
-	errNotStarted   = errors.New("process not started")
+	errNotStarted = errors.New("process not started")
 )
 
 // conPty is a Windows console pseudo-terminal.
@@ -31,12 +31,14 @@ var (
 type conPty struct {
 	handle          windows.Handle
 	inPipe, outPipe *os.File
+	size            windows.Coord
+	done            chan struct{}
 	mtx             sync.RWMutex
 }
 
 var _ Pty = &conPty{}
 
-func newPty() (ConPty, error) {
+func newPty(o *options) (ConPty, error) {
 	ptyIn, inPipeOurs, err := os.Pipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pipes for pseudo console: %w", err)
@@ -49,6 +51,9 @@ func newPty() (ConPty, error) {
 
 	var hpc windows.Handle
 	coord := windows.Coord{X: 80, Y: 25}
+	if o.winsize != nil {
+		coord = windows.Coord{X: int16(o.winsize.Col), Y: int16(o.winsize.Row)}
+	}
 	err = windows.CreatePseudoConsole(coord, windows.Handle(ptyIn.Fd()), windows.Handle(ptyOut.Fd()), 0, &hpc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pseudo console: %w", err)
@@ -65,18 +70,51 @@ func newPty() (ConPty, error) {
 		handle:  hpc,
 		inPipe:  inPipeOurs,
 		outPipe: outPipeOurs,
+		size:    coord,
+		done:    make(chan struct{}),
 	}, nil
 }
 
+func fromFiles(*os.File, *os.File, *options) (UnixPty, error) {
+	return nil, ErrUnsupported
+}
+
+func fromFd(int, int, *options) (UnixPty, error) {
+	return nil, ErrUnsupported
+}
+
 // Close implements Pty.
 func (p *conPty) Close() error {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
+	if p.handle == 0 {
+		return nil
+	}
 
 	windows.ClosePseudoConsole(p.handle)
+	p.handle = 0
+	close(p.done)
 	return errors.Join(p.inPipe.Close(), p.outPipe.Close())
 }
 
+// Done implements Pty.
+func (p *conPty) Done() <-chan struct{} {
+	return p.done
+}
+
+// closedErr returns ErrClosed in place of err if the pseudo console is
+// closed.
+func (p *conPty) closedErr(err error) error {
+	if err != nil {
+		select {
+		case <-p.done:
+			return ErrClosed
+		default:
+		}
+	}
+	return err
+}
+
 // Command implements Pty.
 func (p *conPty) Command(name string, args ...string) *Cmd {
 	c := &Cmd{
@@ -107,22 +145,47 @@ func (*conPty) Name() string {
 
 // Read implements Pty.
 func (p *conPty) Read(b []byte) (n int, err error) {
-	return p.outPipe.Read(b)
+	n, err = p.outPipe.Read(b)
+	return n, p.closedErr(err)
 }
 
 // Resize implements Pty.
 func (p *conPty) Resize(width int, height int) error {
-	p.mtx.RLock()
-	defer p.mtx.RUnlock()
-	if err := windows.ResizePseudoConsole(p.handle, windows.Coord{X: int16(width), Y: int16(height)}); err != nil {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.handle == 0 {
+		return ErrClosed
+	}
+	size := windows.Coord{X: int16(width), Y: int16(height)}
+	if err := windows.ResizePseudoConsole(p.handle, size); err != nil {
 		return fmt.Errorf("failed to resize pseudo console: %w", err)
 	}
+	p.size = size
 	return nil
 }
 
+// ResizeWithPixels implements Pty.
+// The pixel dimensions are ignored.
+func (p *conPty) ResizeWithPixels(width int, height int, _ int, _ int) error {
+	return p.Resize(width, height)
+}
+
+// Size implements Pty.
+// The pseudo console cannot be queried, so this returns the size it was
+// created with or last resized to.
+func (p *conPty) Size() (width int, height int, err error) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	if p.handle == 0 {
+		return 0, 0, ErrClosed
+	}
+	return int(p.size.X), int(p.size.Y), nil
+}
+
 // Write implements Pty.
 func (p *conPty) Write(b []byte) (n int, err error) {
-	return p.inPipe.Write(b)
+	n, err = p.inPipe.Write(b)
+	return n, p.closedErr(err)
 }
 
 // Fd implements Pty.
@@ -132,6 +195,21 @@ func (p *conPty) Fd() uintptr {
 	return uintptr(p.handle)
 }
 
+// SetDeadline implements Pty.
+func (p *conPty) SetDeadline(t time.Time) error {
+	return p.closedErr(errors.Join(p.inPipe.SetWriteDeadline(t), p.outPipe.SetReadDeadline(t)))
+}
+
+// SetReadDeadline implements Pty.
+func (p *conPty) SetReadDeadline(t time.Time) error {
+	return p.closedErr(p.outPipe.SetReadDeadline(t))
+}
+
+// SetWriteDeadline implements Pty.
+func (p *conPty) SetWriteDeadline(t time.Time) error {
+	return p.closedErr(p.inPipe.SetWriteDeadline(t))
+}
+
 // InputPipe implements ConPty.
 func (p *conPty) InputPipe() *os.File {
 	return p.inPipe
@@ -149,7 +227,7 @@ func (p *conPty) updateProcThreadAttribute(attrList *windows.ProcThreadAttribute
 	defer p.mtx.RUnlock()
 
 	if p.handle == 0 {
-		return errClosedConPty
+		return ErrClosed
 	}
 
 	if err := attrList.Update(
@@ -0,0 +1,85 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Reset implements UnixPty.
+func (p *unixPty) Reset(ctx context.Context) error {
+	if err := p.hangupForeground(); err != nil {
+		return err
+	}
+	if err := p.waitReleased(ctx); err != nil {
+		return err
+	}
+	if err := p.Flush(TCIOFLUSH); err != nil {
+		return err
+	}
+	if err := p.SetAttr(TCSANOW, p.initial); err != nil {
+		return err
+	}
+	p.resume()
+	return nil
+}
+
+// hangupForeground sends SIGHUP, followed by SIGCONT to wake stopped jobs, to
+// the foreground process group of the pseudo-terminal and to the group of
+// its session leader.
+func (p *unixPty) hangupForeground() error {
+	pgrp, err := p.ForegroundPgrp()
+	if errors.Is(err, ErrNoForeground) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pgrps := []int{pgrp}
+	if sid, err := unix.Getsid(pgrp); err == nil && sid != pgrp {
+		pgrps = append(pgrps, sid)
+	}
+	for _, pgrp := range pgrps {
+		for _, sig := range []unix.Signal{unix.SIGHUP, unix.SIGCONT} {
+			if err := unix.Kill(-pgrp, sig); err != nil && !errors.Is(err, unix.ESRCH) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// waitReleased waits until no session has the pseudo-terminal as its
+// controlling terminal, which happens when the session leader exits.
+func (p *unixPty) waitReleased(ctx context.Context) error {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for {
+		pgrp, err := p.ForegroundPgrp()
+		if errors.Is(err, ErrNoForeground) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := unix.Getsid(pgrp); errors.Is(err, unix.ESRCH) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("pty: process group %d still holds the terminal: %w", pgrp, ctx.Err())
+		case <-p.done:
+			return ErrClosed
+		case <-ticker.C:
+		}
+	}
+}
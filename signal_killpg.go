@@ -0,0 +1,13 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd netbsd openbsd solaris
+
+package pty
+
+import (
+	"syscall"
+)
+
+// signal sends sig to the foreground process group.
+func (p *unixPty) signal(sig syscall.Signal) error {
+	return p.killForeground(sig)
+}
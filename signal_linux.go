@@ -0,0 +1,25 @@
+package pty
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// signal sends sig to the foreground process group using TIOCSIG, which the
+// kernel delivers on behalf of the terminal. TIOCSIG only supports the signals
+// generated by the line discipline, so others are sent with kill.
+func (p *unixPty) signal(sig syscall.Signal) error {
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		ctrlErr = unix.IoctlSetInt(int(fd), unix.TIOCSIG, int(sig))
+	}); err != nil {
+		return err
+	}
+	if errors.Is(ctrlErr, unix.EINVAL) {
+		return p.killForeground(sig)
+	}
+
+	return ctrlErr
+}
@@ -0,0 +1,45 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Signal implements UnixPty.
+func (p *unixPty) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return errors.New("pty: unsupported signal type")
+	}
+	return p.signal(s)
+}
+
+// Interrupt implements UnixPty.
+func (p *unixPty) Interrupt() error {
+	return p.signal(unix.SIGINT)
+}
+
+// Suspend implements UnixPty.
+func (p *unixPty) Suspend() error {
+	return p.signal(unix.SIGTSTP)
+}
+
+// Hangup implements UnixPty.
+func (p *unixPty) Hangup() error {
+	return p.signal(unix.SIGHUP)
+}
+
+// killForeground sends sig to the foreground process group.
+func (p *unixPty) killForeground(sig syscall.Signal) error {
+	pgrp, err := p.ForegroundPgrp()
+	if err != nil {
+		return err
+	}
+	return unix.Kill(-pgrp, sig)
+}
@@ -1,16 +1,44 @@
 package pty
 
 import (
+	"encoding/binary"
+	"errors"
+
 	"golang.org/x/crypto/ssh"
 )
 
 // ApplyTerminalModes applies the given ssh terminal modes to the given file
 // descriptor.
 func ApplyTerminalModes(fd int, width int, height int, modes ssh.TerminalModes) error {
+	return ApplyTerminalModesWinsize(fd, &Winsize{
+		Row: uint16(height),
+		Col: uint16(width),
+	}, modes)
+}
+
+// ApplyTerminalModesWinsize is like ApplyTerminalModes, but takes the window
+// size including the pixel dimensions, as found in ssh pty requests. A nil
+// window size keeps the current one.
+func ApplyTerminalModesWinsize(fd int, ws *Winsize, modes ssh.TerminalModes) error {
 	if modes == nil {
 		return nil
 	}
-	return applyTerminalModesToFd(fd, width, height, modes)
+	return applyTerminalModesToFd(fd, ws, modes)
+}
+
+// ParseWindowChange parses the payload of an ssh "window-change" request
+// into a window size, including the pixel dimensions.
+// See RFC 4254, section 6.7.
+func ParseWindowChange(payload []byte) (*Winsize, error) {
+	if len(payload) < 16 {
+		return nil, errors.New("pty: invalid window-change payload")
+	}
+	return &Winsize{
+		Col:    uint16(binary.BigEndian.Uint32(payload[0:])),
+		Row:    uint16(binary.BigEndian.Uint32(payload[4:])),
+		Xpixel: uint16(binary.BigEndian.Uint32(payload[8:])),
+		Ypixel: uint16(binary.BigEndian.Uint32(payload[12:])),
+	}, nil
 }
 
 // terminalModeFlagNames maps the SSH terminal mode flags to mnemonic
@@ -7,7 +7,7 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
-func applyTerminalModesToFd(fd int, width int, height int, modes ssh.TerminalModes) error {
+func applyTerminalModesToFd(fd int, ws *Winsize, modes ssh.TerminalModes) error {
 	// TODO
 	return nil
 }
@@ -8,18 +8,22 @@ import (
 
 	"github.com/u-root/u-root/pkg/termios"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/sys/unix"
 )
 
-func applyTerminalModesToFd(fd int, width int, height int, modes ssh.TerminalModes) error {
+func applyTerminalModesToFd(fd int, ws *Winsize, modes ssh.TerminalModes) error {
 	// Get the current TTY configuration.
 	tios, err := termios.GTTY(int(fd))
 	if err != nil {
 		return fmt.Errorf("GTTY: %w", err)
 	}
 
-	// Apply the modes from the SSH request.
-	tios.Row = height
-	tios.Col = width
+	// Apply the modes from the SSH request. GTTY read the current size, which
+	// is kept without a window size.
+	if ws != nil {
+		tios.Row = int(ws.Row)
+		tios.Col = int(ws.Col)
+	}
 
 	for c, v := range modes {
 		if c == ssh.TTY_OP_ISPEED {
@@ -49,5 +53,14 @@ func applyTerminalModesToFd(fd int, width int, height int, modes ssh.TerminalMod
 		return fmt.Errorf("STTY: %w", err)
 	}
 
+	if ws == nil {
+		return nil
+	}
+
+	// STTY only sets the rows and columns, set the pixel dimensions too.
+	if err := unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, ws); err != nil {
+		return fmt.Errorf("TIOCSWINSZ: %w", err)
+	}
+
 	return nil
 }
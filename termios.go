@@ -0,0 +1,69 @@
+package pty
+
+// Optional actions for SetAttr.
+const (
+	// TCSANOW makes the change take effect immediately.
+	TCSANOW = iota
+
+	// TCSADRAIN makes the change take effect after all output written to the
+	// terminal has been transmitted.
+	TCSADRAIN
+
+	// TCSAFLUSH makes the change take effect after all output written to the
+	// terminal has been transmitted, and discards all input received but not
+	// read.
+	TCSAFLUSH
+)
+
+// Queue selectors for Flush.
+const (
+	// TCIFLUSH flushes data received but not read.
+	TCIFLUSH = iota
+
+	// TCOFLUSH flushes data written but not transmitted.
+	TCOFLUSH
+
+	// TCIOFLUSH flushes both data received but not read and data written
+	// but not transmitted.
+	TCIOFLUSH
+)
+
+// NCCS is the size of the control characters array of Termios.
+const NCCS = 20
+
+// Termios holds the line discipline settings of a terminal.
+// The flag bits and the control character indexes are the ones of the
+// platform, such as unix.ECHO and unix.VINTR from golang.org/x/sys/unix.
+// See termios(3) for details.
+type Termios struct {
+	// Iflag holds the input mode flags.
+	Iflag uint32
+
+	// Oflag holds the output mode flags.
+	Oflag uint32
+
+	// Cflag holds the control mode flags.
+	Cflag uint32
+
+	// Lflag holds the local mode flags.
+	Lflag uint32
+
+	// Cc holds the control characters.
+	Cc [NCCS]uint8
+
+	// Ispeed is the input speed. When setting attributes, zero leaves the
+	// input speed unchanged, as encoded in Cflag on Linux.
+	Ispeed uint32
+
+	// Ospeed is the output speed. When setting attributes, zero leaves the
+	// output speed unchanged, as encoded in Cflag on Linux, rather than
+	// hanging up the line. Speeds the system cannot encode make SetAttr
+	// return EINVAL.
+	Ospeed uint32
+}
+
+// State holds the line discipline settings of a terminal saved by MakeRaw,
+// MakeCbreak or DisableEcho, to be restored with Restore.
+type State struct {
+	termios Termios
+}
@@ -0,0 +1,136 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// GetAttr implements UnixPty.
+func (p *unixPty) GetAttr() (*Termios, error) {
+	u, err := p.getTermios()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Termios{
+		Iflag: uint32(u.Iflag),
+		Oflag: uint32(u.Oflag),
+		Cflag: uint32(u.Cflag),
+		Lflag: uint32(u.Lflag),
+	}
+	copy(t.Cc[:], u.Cc[:])
+	t.Ispeed, t.Ospeed = termiosSpeed(u)
+
+	return t, nil
+}
+
+// SetAttr implements UnixPty.
+func (p *unixPty) SetAttr(when int, t *Termios) error {
+	var req uint
+	switch when {
+	case TCSANOW:
+		req = ioctlSetTermios
+	case TCSADRAIN:
+		req = ioctlSetTermiosDrain
+	case TCSAFLUSH:
+		req = ioctlSetTermiosFlush
+	default:
+		return unix.EINVAL
+	}
+
+	// Start from the current settings to preserve the fields that Termios
+	// does not cover, such as the line discipline on Linux.
+	u, err := p.getTermios()
+	if err != nil {
+		return err
+	}
+
+	setUint(&u.Iflag, t.Iflag)
+	setUint(&u.Oflag, t.Oflag)
+	setUint(&u.Cflag, t.Cflag)
+	setUint(&u.Lflag, t.Lflag)
+	copy(u.Cc[:], t.Cc[:])
+	if err := setTermiosSpeed(u, t.Ispeed, t.Ospeed); err != nil {
+		return err
+	}
+
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		ctrlErr = unix.IoctlSetTermios(int(fd), req, u)
+	}); err != nil {
+		return err
+	}
+
+	return ctrlErr
+}
+
+// MakeRaw implements UnixPty.
+func (p *unixPty) MakeRaw() (*State, error) {
+	return p.makeState(func(t *Termios) {
+		t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+		t.Oflag &^= unix.OPOST
+		t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		t.Cflag &^= unix.CSIZE | unix.PARENB
+		t.Cflag |= unix.CS8
+		t.Cc[unix.VMIN] = 1
+		t.Cc[unix.VTIME] = 0
+	})
+}
+
+// MakeCbreak implements UnixPty.
+func (p *unixPty) MakeCbreak() (*State, error) {
+	return p.makeState(func(t *Termios) {
+		t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON
+		t.Cc[unix.VMIN] = 1
+		t.Cc[unix.VTIME] = 0
+	})
+}
+
+// DisableEcho implements UnixPty.
+func (p *unixPty) DisableEcho() (*State, error) {
+	return p.makeState(func(t *Termios) {
+		t.Lflag &^= unix.ECHO | unix.ECHOE | unix.ECHOK | unix.ECHONL
+	})
+}
+
+// Restore implements UnixPty.
+func (p *unixPty) Restore(state *State) error {
+	return p.SetAttr(TCSANOW, &state.termios)
+}
+
+// makeState saves the current settings, applies f to them, and returns the
+// saved state.
+func (p *unixPty) makeState(f func(t *Termios)) (*State, error) {
+	t, err := p.GetAttr()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{termios: *t}
+	f(t)
+	if err := p.SetAttr(TCSANOW, t); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (p *unixPty) getTermios() (*unix.Termios, error) {
+	var u *unix.Termios
+	var ctrlErr error
+	if err := p.control(func(fd uintptr) {
+		u, ctrlErr = unix.IoctlGetTermios(int(fd), ioctlGetTermios)
+	}); err != nil {
+		return nil, err
+	}
+
+	return u, ctrlErr
+}
+
+// setUint sets an integer field of unix.Termios, whose type varies between
+// platforms.
+func setUint[T ~int32 | ~uint32 | ~uint64](dst *T, v uint32) {
+	*dst = T(v)
+}
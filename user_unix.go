@@ -0,0 +1,218 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// defaultShell is the login shell of users that have none set.
+const defaultShell = "/bin/sh"
+
+// login describes a user to start a command as, see Cmd.User.
+type login struct {
+	name, home, shell string
+	uid, gid          uint32
+	groups            []uint32
+}
+
+// lookupLogin looks up a user and, optionally, a group by name or numeric ID.
+func lookupLogin(username, group string) (*login, error) {
+	u, err := lookupUser(username)
+	if err != nil {
+		return nil, err
+	}
+	l := &login{
+		name:  u.Username,
+		home:  u.HomeDir,
+		shell: lookupShell(u.Username),
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("pty: invalid user ID %q: %w", u.Uid, err)
+	}
+	l.uid = uint32(uid)
+
+	gidStr := u.Gid
+	if group != "" {
+		g, err := lookupGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		gidStr = g.Gid
+	}
+	gid, err := strconv.ParseUint(gidStr, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("pty: invalid group ID %q: %w", gidStr, err)
+	}
+	l.gid = uint32(gid)
+
+	gids, err := u.GroupIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range gids {
+		gid, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("pty: invalid group ID %q: %w", s, err)
+		}
+		l.groups = append(l.groups, uint32(gid))
+	}
+
+	return l, nil
+}
+
+func lookupUser(s string) (*user.User, error) {
+	if _, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return user.LookupId(s)
+	}
+	return user.Lookup(s)
+}
+
+func lookupGroup(s string) (*user.Group, error) {
+	if _, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return user.LookupGroupId(s)
+	}
+	return user.LookupGroup(s)
+}
+
+// lookupShell returns the login shell of a user, which os/user does not
+// expose. It asks the system user database, through getent(1) or, on macOS,
+// dscl(1), so that users from NSS modules such as LDAP are found, and falls
+// back to /etc/passwd, then to defaultShell.
+func lookupShell(username string) string {
+	if out, err := exec.Command("getent", "passwd", username).Output(); err == nil {
+		if shell, ok := passwdShell(string(out), username); ok {
+			return shell
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		out, err := exec.Command("dscl", ".", "-read", "/Users/"+username, "UserShell").Output()
+		if shell, ok := strings.CutPrefix(strings.TrimSpace(string(out)), "UserShell: "); err == nil && ok && shell != "" {
+			return shell
+		}
+	}
+
+	b, err := os.ReadFile("/etc/passwd")
+	if err != nil {
+		return defaultShell
+	}
+	if shell, ok := passwdShell(string(b), username); ok {
+		return shell
+	}
+	return defaultShell
+}
+
+// passwdShell returns the login shell of a user from entries in the
+// passwd(5) format.
+func passwdShell(entries, username string) (string, bool) {
+	for _, line := range strings.Split(entries, "\n") {
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(line, ":")
+		if len(fields) == 7 && fields[0] == username && fields[6] != "" {
+			return fields[6], true
+		}
+	}
+	return "", false
+}
+
+// credential returns the credentials to start the process with.
+func (l *login) credential() *syscall.Credential {
+	return &syscall.Credential{
+		Uid:    l.uid,
+		Gid:    l.gid,
+		Groups: l.groups,
+	}
+}
+
+// setup hands the slave end of the pseudo-terminal over to the user.
+func (l *login) setup(p *unixPty) error {
+	// Like login(1), give the terminal to the tty group so that write(1)
+	// and wall(1) work, or keep it private if there is no such group.
+	gid, mode := int(l.gid), os.FileMode(0o600)
+	if g, err := user.LookupGroup("tty"); err == nil {
+		if id, err := strconv.Atoi(g.Gid); err == nil {
+			gid, mode = id, 0o620
+		}
+	}
+	return p.chownSlave(int(l.uid), gid, mode)
+}
+
+// environ returns the login environment of the user.
+func (l *login) environ() []string {
+	return []string{
+		"HOME=" + l.home,
+		"SHELL=" + l.shell,
+		"USER=" + l.name,
+		"LOGNAME=" + l.name,
+	}
+}
+
+// ttyOwner is the ownership and mode of a slave end.
+type ttyOwner struct {
+	uid, gid int
+	mode     os.FileMode
+}
+
+// restore gives f back its original ownership and mode.
+func (o *ttyOwner) restore(f *os.File) error {
+	return errors.Join(f.Chown(o.uid, o.gid), f.Chmod(o.mode))
+}
+
+// chownSlave changes the ownership and mode of the slave end, saving the
+// original ones the first time so that Close restores them.
+func (p *unixPty) chownSlave(uid, gid int, mode os.FileMode) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isClosed() {
+		return ErrClosed
+	}
+	if p.slave == nil {
+		return errNoSlave
+	}
+
+	if p.owner == nil {
+		fi, err := p.slave.Stat()
+		if err != nil {
+			return err
+		}
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok {
+			return errors.New("pty: cannot get slave ownership")
+		}
+		p.owner = &ttyOwner{
+			uid:  int(st.Uid),
+			gid:  int(st.Gid),
+			mode: fi.Mode().Perm(),
+		}
+	}
+
+	if err := p.slave.Chown(uid, gid); err != nil {
+		return err
+	}
+	return p.slave.Chmod(mode)
+}
+
+// restoreOwner gives the slave end back the ownership and mode saved by
+// chownSlave, if any.
+func (p *unixPty) restoreOwner() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.owner == nil || p.isClosed() {
+		return nil
+	}
+
+	err := p.owner.restore(p.slave)
+	p.owner = nil
+	return err
+}
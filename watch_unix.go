@@ -0,0 +1,86 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package pty
+
+import (
+	"context"
+	"time"
+)
+
+// sizePollInterval is the interval at which WatchSize polls the window size
+// for changes made through the slave end. The process that changes the size
+// of its terminal gets SIGWINCH, but the holder of the master end does not.
+const sizePollInterval = 250 * time.Millisecond
+
+// WatchSize implements UnixPty.
+func (p *unixPty) WatchSize(ctx context.Context) <-chan Winsize {
+	ch := make(chan Winsize)
+	last, err := p.Winsize()
+	if err != nil {
+		close(ch)
+		return ch
+	}
+
+	notify := make(chan struct{}, 1)
+	p.watchersMu.Lock()
+	if p.watchers == nil {
+		p.watchers = make(map[chan struct{}]struct{})
+	}
+	p.watchers[notify] = struct{}{}
+	p.watchersMu.Unlock()
+
+	go func() {
+		defer func() {
+			p.watchersMu.Lock()
+			delete(p.watchers, notify)
+			p.watchersMu.Unlock()
+			close(ch)
+		}()
+
+		ticker := time.NewTicker(sizePollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.done:
+				return
+			case <-ticker.C:
+			case <-notify:
+			}
+
+			ws, err := p.Winsize()
+			if err != nil {
+				return
+			}
+			if *ws == *last {
+				continue
+			}
+			last = ws
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.done:
+				return
+			case ch <- *ws:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// notifyWatchers makes the WatchSize goroutines check the window size now.
+func (p *unixPty) notifyWatchers() {
+	p.watchersMu.Lock()
+	defer p.watchersMu.Unlock()
+	for notify := range p.watchers {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+}